@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/hassansin/androidpublisher/acknowledge"
+	"github.com/hassansin/androidpublisher/bulk"
+	"github.com/pkg/errors"
+	androidpublisher "google.golang.org/api/androidpublisher/v3"
+)
+
+//acknowledger looks up and acknowledges purchases of one kind
+type acknowledger struct {
+	//idColumns are the names accepted for the product or subscription id column
+	idColumns []string
+	//pending returns why the purchase doesn't need to be acknowledged, or an empty string if it does
+	pending     acknowledge.Lookup
+	acknowledge func(ctx context.Context, id, token, payload string) error
+}
+
+//acknowledgeOperation acknowledges a single purchase with an optional developer payload
+func acknowledgeOperation(a acknowledger, idParam string) *Operation {
+	return &Operation{
+		Name:   "Acknowledge",
+		Params: []*Param{{Name: idParam, Required: true}, {Name: "Token", Required: true}, {Name: "DeveloperPayload"}},
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
+			return nil, a.acknowledge(ctx, params[0].Value, params[1].Value, params[2].Value)
+		},
+	}
+}
+
+//bulkAcknowledgeOperation finds the unacknowledged purchases of a CSV file and acknowledges them once confirmed.
+//Purchases that couldn't be looked up are listed, but not acknowledged.
+func bulkAcknowledgeOperation(a acknowledger) *Operation {
+	params := append(bulkParams(), &Param{Name: "DeveloperPayload"})
+	return &Operation{
+		Name:    "BulkAcknowledge",
+		Params:  params,
+		Timeout: bulkTimeout,
+		Preview: func(ctx context.Context, params []*Param) (*Preview, error) {
+			table, err := bulk.ReadCSV(params[0].Value)
+			if err != nil {
+				return nil, err
+			}
+			concurrency := 4
+			if params[2].Value != "" {
+				if concurrency, err = strconv.Atoi(params[2].Value); err != nil || concurrency < 1 {
+					return nil, errors.Errorf("invalid concurrency %q", params[2].Value)
+				}
+			}
+			s, err := acknowledge.Select(ctx, acknowledge.FromTable(table, a.idColumns), concurrency, a.pending, func(done, failed, total int) {
+				reportProgress(ctx, Progress{Done: done, Failed: failed, Total: total})
+			})
+			if err != nil {
+				return nil, err
+			}
+			return &Preview{Action: "acknowledge", Count: len(s.Pending), Skipped: len(s.Skipped), Items: s, Plan: s}, nil
+		},
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
+			s, ok := confirmedPlan(ctx).(*acknowledge.Selection)
+			if !ok {
+				return nil, errNotConfirmed
+			}
+			header := []string{a.idColumns[0], "token", "outcome"}
+			table := &bulk.Table{Header: header[:2]}
+			for _, p := range s.Pending {
+				table.Rows = append(table.Rows, []string{p.ID, p.Token})
+			}
+			return bulkRunTable(ctx, params, table, header, func(ctx context.Context, t *bulk.Table, i int) ([]string, error) {
+				id, token := t.Rows[i][0], t.Rows[i][1]
+				row := []string{id, token}
+				//a purchase acknowledged since the preview is left alone
+				reason, err := a.pending(ctx, id, token)
+				if err != nil {
+					return row, err
+				}
+				if reason != "" {
+					return append(row, reason), nil
+				}
+				if err := a.acknowledge(ctx, id, token, params[3].Value); err != nil {
+					return row, err
+				}
+				return append(row, "acknowledged"), nil
+			})
+		},
+	}
+}
+
+func productAcknowledger(service *androidpublisher.Service, pkgName string) acknowledger {
+	s := androidpublisher.NewPurchasesProductsService(service)
+	return acknowledger{
+		idColumns: []string{"productId", "sku"},
+		pending: func(ctx context.Context, id, token string) (string, error) {
+			p, err := s.Get(pkgName, id, token).Context(ctx).Do()
+			if err != nil {
+				return "", err
+			}
+			return acknowledge.ProductReason(p), nil
+		},
+		acknowledge: func(ctx context.Context, id, token, payload string) error {
+			req := &androidpublisher.ProductPurchasesAcknowledgeRequest{DeveloperPayload: payload}
+			return s.Acknowledge(pkgName, id, token, req).Context(ctx).Do()
+		},
+	}
+}
+
+func subscriptionAcknowledger(service *androidpublisher.Service, pkgName string) acknowledger {
+	s := androidpublisher.NewPurchasesSubscriptionsService(service)
+	return acknowledger{
+		idColumns: []string{"subscriptionId", "sku", "productId"},
+		pending: func(ctx context.Context, id, token string) (string, error) {
+			sub, err := s.Get(pkgName, id, token).Context(ctx).Do()
+			if err != nil {
+				return "", err
+			}
+			return acknowledge.SubscriptionReason(sub), nil
+		},
+		acknowledge: func(ctx context.Context, id, token, payload string) error {
+			req := &androidpublisher.SubscriptionPurchasesAcknowledgeRequest{DeveloperPayload: payload}
+			return s.Acknowledge(pkgName, id, token, req).Context(ctx).Do()
+		},
+	}
+}
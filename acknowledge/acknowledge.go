@@ -0,0 +1,105 @@
+//Package acknowledge selects the purchases of a bulk acknowledgement that still have to be acknowledged
+package acknowledge
+
+import (
+	"context"
+
+	"github.com/hassansin/androidpublisher/apierror"
+	"github.com/hassansin/androidpublisher/bulk"
+	"github.com/hassansin/androidpublisher/enums"
+	androidpublisher "google.golang.org/api/androidpublisher/v3"
+)
+
+//Purchase is a product or subscription purchase
+type Purchase struct {
+	ID    string `json:"id"`
+	Token string `json:"token"`
+}
+
+//Skipped is a purchase that isn't acknowledged and why
+type Skipped struct {
+	Purchase
+	Reason string `json:"reason"`
+}
+
+//Selection splits purchases into the ones to acknowledge and the ones to leave alone
+type Selection struct {
+	Pending []Purchase `json:"pending"`
+	Skipped []Skipped  `json:"skipped,omitempty"`
+	//Errors are the purchases that couldn't be looked up, they aren't acknowledged either
+	Errors []Skipped `json:"errors,omitempty"`
+}
+
+//Lookup returns why a purchase doesn't need to be acknowledged, or an empty string if it does
+type Lookup func(ctx context.Context, id, token string) (string, error)
+
+//FromTable returns the purchases in the id and token columns of a table, in the order of the rows
+func FromTable(t *bulk.Table, idColumns []string) []Purchase {
+	id, token := t.Column(idColumns...), t.Column("token", "purchaseToken")
+	purchases := make([]Purchase, len(t.Rows))
+	for i := range t.Rows {
+		purchases[i] = Purchase{ID: t.Value(i, id), Token: t.Value(i, token)}
+	}
+	return purchases
+}
+
+//Select looks up every purchase and keeps the ones that have to be acknowledged, in their order. Incomplete and
+//repeated purchases are skipped without a lookup.
+func Select(ctx context.Context, purchases []Purchase, concurrency int, lookup Lookup, progress bulk.Progress) (*Selection, error) {
+	reasons := make([]string, len(purchases))
+	errs := make([]error, len(purchases))
+	var unique []int
+	seen := map[Purchase]bool{}
+	for i, p := range purchases {
+		switch {
+		case p.ID == "" || p.Token == "":
+			reasons[i] = "missing id or token"
+		case seen[p]:
+			reasons[i] = "listed before"
+		default:
+			seen[p] = true
+			unique = append(unique, i)
+		}
+	}
+	err := bulk.Run(ctx, len(unique), concurrency, func(ctx context.Context, n int) error {
+		i := unique[n]
+		reasons[i], errs[i] = lookup(ctx, purchases[i].ID, purchases[i].Token)
+		return errs[i]
+	}, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Selection{}
+	for i, p := range purchases {
+		switch {
+		case errs[i] != nil:
+			s.Errors = append(s.Errors, Skipped{p, apierror.Summary(errs[i])})
+		case reasons[i] != "":
+			s.Skipped = append(s.Skipped, Skipped{p, reasons[i]})
+		default:
+			s.Pending = append(s.Pending, p)
+		}
+	}
+	return s, nil
+}
+
+//ProductReason returns why a product purchase doesn't need to be acknowledged, or an empty string if it does
+func ProductReason(p *androidpublisher.ProductPurchase) string {
+	switch {
+	case p.AcknowledgementState == 1:
+		return "already acknowledged"
+	case p.PurchaseState != 0:
+		return "not purchased (" + enums.PurchaseState(p.PurchaseState) + ")"
+	}
+	return ""
+}
+
+//SubscriptionReason returns why a subscription purchase doesn't need to be acknowledged, or an empty string if it
+//does
+func SubscriptionReason(s *androidpublisher.SubscriptionPurchase) string {
+	if s.AcknowledgementState == 1 {
+		return "already acknowledged"
+	}
+	return ""
+}
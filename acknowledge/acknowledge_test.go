@@ -0,0 +1,81 @@
+package acknowledge
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/hassansin/androidpublisher/bulk"
+	androidpublisher "google.golang.org/api/androidpublisher/v3"
+)
+
+func TestReasons(t *testing.T) {
+	products := map[string]*androidpublisher.ProductPurchase{
+		"":                          {PurchaseState: 0, AcknowledgementState: 0},
+		"already acknowledged":      {PurchaseState: 0, AcknowledgementState: 1},
+		"not purchased (cancelled)": {PurchaseState: 1},
+		"not purchased (pending)":   {PurchaseState: 2},
+		"not purchased (7)":         {PurchaseState: 7},
+	}
+	for want, p := range products {
+		if got := ProductReason(p); got != want {
+			t.Errorf("ProductReason(%+v) = %q, expected %q", p, got, want)
+		}
+	}
+	if got := SubscriptionReason(&androidpublisher.SubscriptionPurchase{AcknowledgementState: 1}); got != "already acknowledged" {
+		t.Errorf("unexpected reason %q for an acknowledged subscription", got)
+	}
+	if got := SubscriptionReason(&androidpublisher.SubscriptionPurchase{}); got != "" {
+		t.Errorf("unexpected reason %q for a pending subscription", got)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	table := &bulk.Table{
+		Header: []string{"sku", "purchaseToken"},
+		Rows: [][]string{
+			{"coins", "pending-1"},
+			{"coins", "acknowledged"},
+			{"coins", "broken"},
+			{"", "no-id"},
+			{"coins", "pending-2"},
+			{"coins", "pending-1"},
+		},
+	}
+	var mu sync.Mutex
+	var lookups []string
+	lookup := func(ctx context.Context, id, token string) (string, error) {
+		mu.Lock()
+		lookups = append(lookups, token)
+		mu.Unlock()
+		switch token {
+		case "acknowledged":
+			return "already acknowledged", nil
+		case "broken":
+			return "", errors.New("purchase not found")
+		}
+		return "", nil
+	}
+
+	s, err := Select(context.Background(), FromTable(table, []string{"productId", "sku"}), 2, lookup, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Selection{
+		Pending: []Purchase{{"coins", "pending-1"}, {"coins", "pending-2"}},
+		Skipped: []Skipped{
+			{Purchase{"coins", "acknowledged"}, "already acknowledged"},
+			{Purchase{"", "no-id"}, "missing id or token"},
+			{Purchase{"coins", "pending-1"}, "listed before"},
+		},
+		Errors: []Skipped{{Purchase{"coins", "broken"}, "purchase not found"}},
+	}
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("Select = %+v, expected %+v", s, want)
+	}
+	if len(lookups) != 4 {
+		t.Errorf("%v lookups, expected one per complete and unique purchase", len(lookups))
+	}
+}
@@ -0,0 +1,186 @@
+//Package apierror explains errors returned by the Google Play Developer API
+package apierror
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/logrusorgru/aurora"
+	"github.com/pkg/errors"
+	"google.golang.org/api/googleapi"
+)
+
+//Item is one entry in the errors list of an API error
+type Item struct {
+	Domain       string `json:"domain"`
+	Reason       string `json:"reason"`
+	Message      string `json:"message"`
+	Location     string `json:"location"`
+	LocationType string `json:"locationType"`
+}
+
+//Details is the decoded form of a *googleapi.Error
+type Details struct {
+	Code    int
+	Message string
+	Items   []Item
+	Header  http.Header
+	Hints   []string
+}
+
+//reasons maps error reasons to what should be done about them
+var reasons = map[string]string{
+	"purchaseTokenDoesNotMatchPackageName":    "The purchase token belongs to another app. Check that --package is the package the purchase was made in.",
+	"purchaseTokenDoesNotMatchSubscriptionId": "The purchase token belongs to another subscription. Check the SubscriptionId parameter.",
+	"productNotOwnedByUser":                   "The purchase token doesn't belong to this product. Check the ProductID parameter.",
+	"invalidPurchaseToken":                    "The purchase token is malformed. Make sure it was copied completely.",
+	"projectNotLinked":                        "The Google Cloud project of the service account isn't linked to the Play Console. Link it under Settings > API access.",
+	"permissionDenied":                        "The service account doesn't have the permission this call needs. Grant it in the Play Console under Users and permissions.",
+	"forbidden":                               "The service account doesn't have access to this app. Grant it in the Play Console under Users and permissions.",
+	"insufficientPermissions":                 "The service account doesn't have the permission this call needs. Grant it in the Play Console under Users and permissions.",
+	"applicationNotFound":                     "No app was found for the package name. Check --package and that the app has been uploaded at least once.",
+	"required":                                "A required parameter or the authorization is missing.",
+	"authError":                               "The credentials were rejected. Check that the service account key is valid and hasn't been revoked.",
+	"rateLimitExceeded":                       "Too many requests in a short time. Wait a moment and try again, or lower the request rate.",
+	"userRateLimitExceeded":                   "Too many requests in a short time. Wait a moment and try again, or lower the request rate.",
+	"quotaExceeded":                           "The daily API quota of the project is used up. Wait until it resets or request a higher quota.",
+	"dailyLimitExceeded":                      "The daily API quota of the project is used up. Wait until it resets or request a higher quota.",
+	"subscriptionPurchaseNoLongerAvailable":   "The subscription expired too long ago and its purchase is no longer available.",
+	"purchaseNoLongerAvailable":               "The purchase is too old and is no longer available.",
+}
+
+//codes explain status codes when the reason isn't known
+var codes = map[int]string{
+	http.StatusBadRequest:      "The request is invalid. Check the parameters and the request body.",
+	http.StatusUnauthorized:    "The credentials were rejected. Check the service account key.",
+	http.StatusForbidden:       "The service account isn't allowed to do this. Check its permissions in the Play Console.",
+	http.StatusNotFound:        "The resource doesn't exist. Check the IDs and the package name.",
+	http.StatusGone:            "The resource is no longer available.",
+	http.StatusConflict:        "The resource was changed concurrently, e.g. another edit is open. Try again.",
+	http.StatusTooManyRequests: "Too many requests. Wait a moment and try again.",
+}
+
+//Describe decodes err if it's a Google API error, also when it was wrapped with errors.Wrap
+func Describe(err error) (*Details, bool) {
+	apiErr, ok := errors.Cause(err).(*googleapi.Error)
+	if !ok {
+		return nil, false
+	}
+	d := &Details{
+		Code:    apiErr.Code,
+		Message: apiErr.Message,
+		Header:  apiErr.Header,
+	}
+	var reply struct {
+		Error struct {
+			Message string `json:"message"`
+			Errors  []Item `json:"errors"`
+		} `json:"error"`
+	}
+	if json.Unmarshal([]byte(apiErr.Body), &reply) == nil && len(reply.Error.Errors) > 0 {
+		d.Items = reply.Error.Errors
+		if d.Message == "" {
+			d.Message = reply.Error.Message
+		}
+	} else {
+		for _, item := range apiErr.Errors {
+			d.Items = append(d.Items, Item{Reason: item.Reason, Message: item.Message})
+		}
+	}
+	if d.Message == "" {
+		d.Message = http.StatusText(d.Code)
+	}
+
+	seen := map[string]bool{}
+	for _, item := range d.Items {
+		if hint, ok := reasons[item.Reason]; ok && !seen[hint] {
+			d.Hints = append(d.Hints, hint)
+			seen[hint] = true
+		}
+	}
+	if len(d.Hints) == 0 {
+		if hint, ok := hintFromMessage(d.Message); ok {
+			d.Hints = append(d.Hints, hint)
+		} else if hint, ok := codes[d.Code]; ok {
+			d.Hints = append(d.Hints, hint)
+		}
+	}
+	return d, true
+}
+
+//hintFromMessage recognizes errors whose reason is too generic, e.g. "invalid"
+func hintFromMessage(msg string) (string, bool) {
+	m := strings.ToLower(msg)
+	switch {
+	case strings.Contains(m, "does not match the package name"):
+		return reasons["purchaseTokenDoesNotMatchPackageName"], true
+	case strings.Contains(m, "does not match the subscription id"):
+		return reasons["purchaseTokenDoesNotMatchSubscriptionId"], true
+	case strings.Contains(m, "not been linked"):
+		return reasons["projectNotLinked"], true
+	case strings.Contains(m, "insufficient permissions"):
+		return reasons["permissionDenied"], true
+	}
+	return "", false
+}
+
+//Summary is a one line description of err, e.g. "404 Not Found: No application was found"
+func Summary(err error) string {
+	d, ok := Describe(err)
+	if !ok {
+		return err.Error()
+	}
+	return fmt.Sprintf("%v %v", d.Code, d.Message)
+}
+
+//Render returns a coloured, multi-line description of err
+func Render(err error) string {
+	d, ok := Describe(err)
+	if !ok {
+		return err.Error()
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%v %v\n", aurora.Red(fmt.Sprintf("HTTP %v %v", d.Code, http.StatusText(d.Code))).Bold(), d.Message)
+	if len(d.Items) > 0 {
+		fmt.Fprintf(&b, "\n%v\n", aurora.Bold("Errors"))
+		for _, item := range d.Items {
+			field(&b, "reason", item.Reason)
+			field(&b, "domain", item.Domain)
+			field(&b, "message", item.Message)
+			if item.LocationType != "" {
+				field(&b, "location", fmt.Sprintf("%v (%v)", item.Location, item.LocationType))
+			} else {
+				field(&b, "location", item.Location)
+			}
+			b.WriteString("\n")
+		}
+	}
+	if len(d.Hints) > 0 {
+		fmt.Fprintf(&b, "\n%v\n", aurora.Bold("How to fix"))
+		for _, hint := range d.Hints {
+			fmt.Fprintf(&b, " %v\n", aurora.Brown(hint))
+		}
+	}
+	if len(d.Header) > 0 {
+		fmt.Fprintf(&b, "\n%v\n", aurora.Bold("Response headers"))
+		keys := make([]string, 0, len(d.Header))
+		for k := range d.Header {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(&b, " %v: %v\n", aurora.Cyan(k), strings.Join(d.Header[k], ", "))
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func field(b *strings.Builder, name, value string) {
+	if value == "" {
+		return
+	}
+	fmt.Fprintf(b, " %-9v %v\n", aurora.Cyan(name+":"), value)
+}
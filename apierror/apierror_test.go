@@ -0,0 +1,71 @@
+package apierror
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"google.golang.org/api/googleapi"
+)
+
+func TestDescribe(t *testing.T) {
+	err := &googleapi.Error{
+		Code:    400,
+		Message: "The purchase token does not match the package name.",
+		Body: `{"error": {"errors": [{"domain": "androidpublisher", "reason": "purchaseTokenDoesNotMatchPackageName",
+			"message": "The purchase token does not match the package name.", "locationType": "parameter", "location": "token"}],
+			"code": 400, "message": "The purchase token does not match the package name."}}`,
+	}
+	d, ok := Describe(err)
+	if !ok {
+		t.Fatal("expected a google api error")
+	}
+	if len(d.Items) != 1 || d.Items[0].Domain != "androidpublisher" || d.Items[0].Location != "token" {
+		t.Errorf("unexpected items %+v", d.Items)
+	}
+	if len(d.Hints) != 1 || !strings.Contains(d.Hints[0], "--package") {
+		t.Errorf("unexpected hints %v", d.Hints)
+	}
+	out := Render(err)
+	for _, s := range []string{"HTTP 400", "purchaseTokenDoesNotMatchPackageName", "token (parameter)", "How to fix"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Render() is missing %q:\n%v", s, out)
+		}
+	}
+
+	d, _ = Describe(&googleapi.Error{Code: 403, Body: "forbidden"})
+	if d.Message != "Forbidden" || len(d.Hints) != 1 {
+		t.Errorf("unexpected details for non JSON body %+v", d)
+	}
+
+	if _, ok := Describe(errors.New("request cancelled")); ok {
+		t.Error("plain errors must not be described")
+	}
+	if s := Summary(errors.New("request cancelled")); s != "request cancelled" {
+		t.Errorf("Summary() = %q", s)
+	}
+}
+
+func TestDescribeWrapped(t *testing.T) {
+	err := errors.Wrap(&googleapi.Error{
+		Code: 404,
+		Body: `{"error": {"errors": [{"domain": "global", "reason": "applicationNotFound", "message": "No application was found."}],
+			"code": 404, "message": "No application was found."}}`,
+	}, "get listing")
+	d, ok := Describe(err)
+	if !ok {
+		t.Fatal("wrapped google api errors must be described")
+	}
+	if d.Code != 404 || len(d.Items) != 1 || d.Items[0].Reason != "applicationNotFound" {
+		t.Errorf("unexpected details %+v", d)
+	}
+	if s := Summary(err); s != "404 No application was found." {
+		t.Errorf("Summary() = %q", s)
+	}
+	out := Render(err)
+	for _, s := range []string{"HTTP 404", "applicationNotFound", "How to fix"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Render() is missing %q:\n%v", s, out)
+		}
+	}
+}
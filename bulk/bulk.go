@@ -0,0 +1,136 @@
+//Package bulk runs an operation for every row of a CSV file with bounded concurrency
+package bulk
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+//Table is a CSV file with a header row
+type Table struct {
+	Header []string
+	Rows   [][]string
+}
+
+//ReadCSV reads a CSV file, the first row must be the header
+func ReadCSV(path string) (*Table, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+//Read reads CSV data, the first row must be the header
+func Read(r io.Reader) (*Table, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read CSV")
+	}
+	if len(records) == 0 {
+		return nil, errors.New("CSV file is empty")
+	}
+	t := &Table{Header: records[0]}
+	for _, row := range records[1:] {
+		if len(row) == 1 && strings.TrimSpace(row[0]) == "" {
+			continue
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	return t, nil
+}
+
+//Column returns the index of the first column named like one of names, ignoring case, or -1
+func (t *Table) Column(names ...string) int {
+	for _, name := range names {
+		for i, h := range t.Header {
+			if strings.EqualFold(strings.TrimSpace(h), name) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+//Value returns the value of column col in the idx-th row
+func (t *Table) Value(idx, col int) string {
+	if col < 0 || col >= len(t.Rows[idx]) {
+		return ""
+	}
+	return strings.TrimSpace(t.Rows[idx][col])
+}
+
+//WriteCSV writes the header and rows to path
+func WriteCSV(path string, header []string, rows [][]string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	w.Write(header)
+	w.WriteAll(rows)
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+//Progress is called whenever a row is processed
+type Progress func(done, failed, total int)
+
+//Run calls fn for every index in [0, total) from at most concurrency goroutines.
+//It returns once all rows are processed, or ctx is done.
+func Run(ctx context.Context, total, concurrency int, fn func(ctx context.Context, i int) error, progress Progress) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	var (
+		mu           sync.Mutex
+		done, failed int
+		wg           sync.WaitGroup
+	)
+	indexes := make(chan int)
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				err := fn(ctx, i)
+				mu.Lock()
+				done++
+				if err != nil {
+					failed++
+				}
+				d, f := done, failed
+				mu.Unlock()
+				if progress != nil {
+					progress(d, f, total)
+				}
+			}
+		}()
+	}
+	var err error
+loop:
+	for i := 0; i < total; i++ {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			err = ctx.Err()
+			break loop
+		}
+	}
+	close(indexes)
+	wg.Wait()
+	return err
+}
@@ -0,0 +1,146 @@
+package bulk
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	table, err := Read(strings.NewReader("SubscriptionId, purchaseToken\nmonthly,abc\n\nyearly, def\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(table.Rows) != 2 {
+		t.Fatalf("read %v rows", len(table.Rows))
+	}
+	id, token := table.Column("subscriptionId"), table.Column("token", "purchaseToken")
+	if id != 0 || token != 1 || table.Value(1, token) != "def" || table.Value(1, 5) != "" {
+		t.Errorf("unexpected columns %v %v", id, token)
+	}
+	if _, err := Read(strings.NewReader("")); err == nil {
+		t.Error("expected error for empty file")
+	}
+}
+
+func TestRun(t *testing.T) {
+	var running, max int32
+	var last [3]int
+	err := Run(context.Background(), 20, 3, func(ctx context.Context, i int) error {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			m := atomic.LoadInt32(&max)
+			if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+				break
+			}
+		}
+		defer atomic.AddInt32(&running, -1)
+		if i%5 == 0 {
+			return errors.New("failed")
+		}
+		return nil
+	}, func(done, failed, total int) {
+		if done == total {
+			last = [3]int{done, failed, total}
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if max > 3 {
+		t.Errorf("ran %v rows concurrently", max)
+	}
+	if last != [3]int{20, 4, 20} {
+		t.Errorf("last progress %v", last)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := Run(ctx, 5, 1, func(context.Context, int) error { return nil }, nil); err != context.Canceled {
+		t.Errorf("Run() with cancelled context = %v", err)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.csv")
+	if err := WriteCSV(path, []string{"a", "b"}, [][]string{{"1", "x,y"}}); err != nil {
+		t.Fatal(err)
+	}
+	table, err := ReadCSV(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Value(0, 1) != "x,y" {
+		t.Errorf("unexpected table %+v", table)
+	}
+}
+
+func TestReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.csv")
+	r, err := OpenReport(path, []string{"orderId", "action"}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Write([]string{"GPA.1", "refund"}, "")
+	r.Write([]string{"GPA.2", "refund"}, "404 not found")
+	if !r.Done("GPA.1") || r.Done("GPA.2") {
+		t.Error("unexpected done items")
+	}
+	r.Close()
+
+	r, err = OpenReport(path, []string{"orderId", "action"}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Done("GPA.1") || r.Done("GPA.2") || r.Done("GPA.3") {
+		t.Error("unexpected done items after resuming")
+	}
+	r.Write([]string{"GPA.2", "refund"}, "")
+	r.Close()
+
+	table, err := ReadCSV(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(table.Rows) != 3 || table.Value(2, table.Column("status")) != StatusOK {
+		t.Errorf("unexpected report %v", table.Rows)
+	}
+	if _, err := OpenReport(path, []string{"subscriptionId", "token"}, 2); err == nil {
+		t.Error("expected error for report with different columns")
+	}
+}
+
+func TestReadReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.csv")
+	r, err := ReadReport(path, []string{"orderId", "action"}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Done("GPA.1", "refund") {
+		t.Error("a missing report has no items done")
+	}
+	if err := r.Write([]string{"GPA.1", "refund"}, ""); err == nil {
+		t.Error("expected an error writing a report that was only read")
+	}
+	r.Close()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("reading created the report: %v", err)
+	}
+
+	w, err := OpenReport(path, []string{"orderId", "action"}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]string{"GPA.1", "refund"}, "")
+	w.Close()
+	if r, err = ReadReport(path, []string{"orderId", "action"}, 2); err != nil {
+		t.Fatal(err)
+	}
+	if !r.Done("GPA.1", "refund") || r.Done("GPA.1", "refund and revoke") {
+		t.Error("expected the action to be part of the key")
+	}
+}
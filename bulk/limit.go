@@ -0,0 +1,35 @@
+package bulk
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+//Limit wraps fn so that it's started at most rate times per second across all goroutines
+func Limit(rate float64, fn func(ctx context.Context, i int) error) func(ctx context.Context, i int) error {
+	if rate <= 0 {
+		return fn
+	}
+	interval := time.Duration(float64(time.Second) / rate)
+	var (
+		mu   sync.Mutex
+		next time.Time
+	)
+	return func(ctx context.Context, i int) error {
+		mu.Lock()
+		now := time.Now()
+		if next.Before(now) {
+			next = now
+		}
+		wait := next.Sub(now)
+		next = next.Add(interval)
+		mu.Unlock()
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		return fn(ctx, i)
+	}
+}
@@ -0,0 +1,128 @@
+package bulk
+
+import (
+	"encoding/csv"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+//Report is a CSV file with the outcome of every item of a bulk operation. Each row starts with the columns
+//identifying the item and ends with status, error and time columns. Rows are flushed as soon as they're written,
+//so an interrupted run can be resumed by skipping the items that are already done.
+type Report struct {
+	mu   sync.Mutex
+	f    *os.File
+	w    *csv.Writer
+	keys int
+	done map[string]bool
+}
+
+//Report statuses
+const (
+	StatusOK     = "ok"
+	StatusFailed = "failed"
+)
+
+//OpenReport opens the report at path, creating it if needed. The first keys columns of header identify an item.
+func OpenReport(path string, header []string, keys int) (*Report, error) {
+	r, exists, err := readReport(path, header, keys)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	r.f, r.w = f, csv.NewWriter(f)
+	if !exists {
+		if err := r.write(reportHeader(header)); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+//ReadReport reads the report at path without creating or changing it, a missing report has no items done.
+//Write fails on a report that was only read.
+func ReadReport(path string, header []string, keys int) (*Report, error) {
+	r, _, err := readReport(path, header, keys)
+	return r, err
+}
+
+func reportHeader(header []string) []string {
+	return append(header[:len(header):len(header)], "status", "error", "time")
+}
+
+func readReport(path string, header []string, keys int) (r *Report, exists bool, err error) {
+	header = reportHeader(header)
+	r = &Report{keys: keys, done: map[string]bool{}}
+	info, err := os.Stat(path)
+	if err != nil || info.Size() == 0 {
+		return r, false, nil
+	}
+	t, err := ReadCSV(path)
+	if err != nil {
+		return nil, false, err
+	}
+	if strings.Join(t.Header, ",") != strings.Join(header, ",") {
+		return nil, false, errors.Errorf("%v is a report with different columns", path)
+	}
+	status := len(header) - 3
+	for i := range t.Rows {
+		if t.Value(i, status) == StatusOK {
+			r.done[r.key(t.Rows[i])] = true
+		}
+	}
+	return r, true, nil
+}
+
+func (r *Report) key(row []string) string {
+	if len(row) > r.keys {
+		row = row[:r.keys]
+	}
+	return strings.Join(row, "\x00")
+}
+
+//Done reports whether the item with the given key columns succeeded in a previous run
+func (r *Report) Done(key ...string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.done[r.key(key)]
+}
+
+//Write appends the outcome of an item, an empty msg means it succeeded
+func (r *Report) Write(row []string, msg string) error {
+	status := StatusOK
+	if msg != "" {
+		status = StatusFailed
+	}
+	row = append(row[:len(row):len(row)], status, msg, time.Now().UTC().Format(time.RFC3339))
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.w == nil {
+		return errors.New("the report was opened read-only")
+	}
+	if status == StatusOK {
+		r.done[r.key(row)] = true
+	}
+	return r.write(row)
+}
+
+func (r *Report) write(row []string) error {
+	r.w.Write(row)
+	r.w.Flush()
+	return r.w.Error()
+}
+
+//Close closes the report file
+func (r *Report) Close() error {
+	if r.f == nil {
+		return nil
+	}
+	return r.f.Close()
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hassansin/androidpublisher/apierror"
+	"github.com/hassansin/androidpublisher/catalog"
+	androidpublisher "google.golang.org/api/androidpublisher/v3"
+)
+
+//CatalogResult summarizes an export or import of the in-app product catalog
+type CatalogResult struct {
+	File     string   `json:"file"`
+	Products int      `json:"products,omitempty"`
+	Inserted int      `json:"inserted,omitempty"`
+	Updated  int      `json:"updated,omitempty"`
+	Deleted  int      `json:"deleted,omitempty"`
+	Errors   []string `json:"errors,omitempty"`
+}
+
+//listProducts returns every in-app product, following the page tokens
+func listProducts(ctx context.Context, s *androidpublisher.InappproductsService, pkgName string) ([]*androidpublisher.InAppProduct, error) {
+	var products []*androidpublisher.InAppProduct
+	token := ""
+	for {
+		call := s.List(pkgName)
+		if token != "" {
+			call.Token(token)
+		}
+		res, err := call.Context(ctx).Do()
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, res.Inappproduct...)
+		if res.TokenPagination == nil || res.TokenPagination.NextPageToken == "" {
+			return products, nil
+		}
+		token = res.TokenPagination.NextPageToken
+	}
+}
+
+//exportCatalogOperation writes every in-app product to a CSV or JSON file
+func exportCatalogOperation(service *androidpublisher.Service, pkgName string) *Operation {
+	return &Operation{
+		Name:   "Export",
+		Params: []*Param{{Name: "File(.csv/.json)", Required: true}},
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
+			products, err := listProducts(ctx, androidpublisher.NewInappproductsService(service), pkgName)
+			if err != nil {
+				return nil, err
+			}
+			if err := catalog.Write(params[0].Value, products); err != nil {
+				return nil, err
+			}
+			return CatalogResult{File: params[0].Value, Products: len(products)}, nil
+		},
+	}
+}
+
+//importCatalogOperation makes the live catalog match a CSV or JSON file, once the planned changes are confirmed.
+//The confirmed plan is applied as it is, changes to the file or the catalog after the preview aren't picked up.
+func importCatalogOperation(service *androidpublisher.Service, pkgName string) *Operation {
+	plan := func(ctx context.Context, params []*Param) (*catalog.Plan, error) {
+		local, err := catalog.Read(params[0].Value)
+		if err != nil {
+			return nil, err
+		}
+		live, err := listProducts(ctx, androidpublisher.NewInappproductsService(service), pkgName)
+		if err != nil {
+			return nil, err
+		}
+		return catalog.Compare(local, live, strings.ToLower(params[1].Value) == "true")
+	}
+	return &Operation{
+		Name:    "Import",
+		Params:  []*Param{{Name: "File(.csv/.json)", Required: true}, {Name: "DeleteMissing(true/false)"}, {Name: "AutoConvertMissingPrices(true/false)"}},
+		Timeout: bulkTimeout,
+		Preview: func(ctx context.Context, params []*Param) (*Preview, error) {
+			p, err := plan(ctx, params)
+			if err != nil {
+				return nil, err
+			}
+			return &Preview{Action: "change", Count: p.Len(), Items: p, Plan: p}, nil
+		},
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
+			p, ok := confirmedPlan(ctx).(*catalog.Plan)
+			if !ok {
+				return nil, errNotConfirmed
+			}
+			s := androidpublisher.NewInappproductsService(service)
+			convert := strings.ToLower(params[2].Value) == "true"
+			result := CatalogResult{File: params[0].Value}
+			progress := Progress{Total: p.Len()}
+			done := func(sku string, err error, count *int) {
+				progress.Done++
+				if err != nil {
+					progress.Failed++
+					result.Errors = append(result.Errors, sku+": "+apierror.Summary(err))
+				} else {
+					*count++
+				}
+				reportProgress(ctx, progress)
+			}
+			for _, product := range p.Inserts {
+				product.PackageName = pkgName
+				_, err := s.Insert(pkgName, product).AutoConvertMissingPrices(convert).Context(ctx).Do()
+				done(product.Sku, err, &result.Inserted)
+			}
+			for _, u := range p.Updates {
+				u.Product.PackageName = pkgName
+				_, err := s.Update(pkgName, u.Sku, u.Product).AutoConvertMissingPrices(convert).Context(ctx).Do()
+				done(u.Sku, err, &result.Updated)
+			}
+			for _, sku := range p.Deletes {
+				done(sku, s.Delete(pkgName, sku).Context(ctx).Do(), &result.Deleted)
+			}
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return result, nil
+		},
+	}
+}
@@ -0,0 +1,327 @@
+//Package catalog converts the in-app product catalog to and from CSV and JSON files and plans the changes
+//needed to make the live catalog match a file
+package catalog
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hassansin/androidpublisher/bulk"
+	"github.com/hassansin/androidpublisher/diff"
+	"github.com/pkg/errors"
+	androidpublisher "google.golang.org/api/androidpublisher/v3"
+)
+
+//Column names of the CSV format. Localized and per-country columns are suffixed by the language or region,
+//e.g. title:en-US or price:DE
+const (
+	colSku                = "sku"
+	colStatus             = "status"
+	colPurchaseType       = "purchaseType"
+	colDefaultLanguage    = "defaultLanguage"
+	colPrice              = "price"
+	colSubscriptionPeriod = "subscriptionPeriod"
+	colTrialPeriod        = "trialPeriod"
+	colGracePeriod        = "gracePeriod"
+	colTitle              = "title:"
+	colDescription        = "description:"
+	colBenefits           = "benefits:"
+	colRegionPrice        = "price:"
+)
+
+//benefitSeparator separates the benefits of a listing in a CSV cell
+const benefitSeparator = "|"
+
+//Read reads the products from a .json or .csv file
+func Read(path string) ([]*androidpublisher.InAppProduct, error) {
+	if isJSON(path) {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var products []*androidpublisher.InAppProduct
+		if err := json.Unmarshal(data, &products); err != nil {
+			return nil, errors.Wrapf(err, "unable to parse %v", path)
+		}
+		return products, nil
+	}
+	t, err := bulk.ReadCSV(path)
+	if err != nil {
+		return nil, err
+	}
+	return FromCSV(t)
+}
+
+//Write writes the products to a .json or .csv file
+func Write(path string, products []*androidpublisher.InAppProduct) error {
+	if isJSON(path) {
+		data, err := json.MarshalIndent(products, "", "  ")
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(path, data, 0644)
+	}
+	header, rows := ToCSV(products)
+	return bulk.WriteCSV(path, header, rows)
+}
+
+func isJSON(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".json")
+}
+
+//ToCSV flattens the products to one row each
+func ToCSV(products []*androidpublisher.InAppProduct) ([]string, [][]string) {
+	languages, regions := map[string]bool{}, map[string]bool{}
+	benefits := false
+	for _, p := range products {
+		for lang, l := range p.Listings {
+			languages[lang] = true
+			benefits = benefits || len(l.Benefits) > 0
+		}
+		for region := range p.Prices {
+			regions[region] = true
+		}
+	}
+	header := []string{colSku, colStatus, colPurchaseType, colDefaultLanguage, colPrice, colSubscriptionPeriod, colTrialPeriod, colGracePeriod}
+	for _, lang := range sorted(languages) {
+		header = append(header, colTitle+lang, colDescription+lang)
+		if benefits {
+			header = append(header, colBenefits+lang)
+		}
+	}
+	for _, region := range sorted(regions) {
+		header = append(header, colRegionPrice+region)
+	}
+
+	rows := make([][]string, len(products))
+	for i, p := range products {
+		row := []string{p.Sku, p.Status, p.PurchaseType, p.DefaultLanguage, formatPrice(p.DefaultPrice), p.SubscriptionPeriod, p.TrialPeriod, p.GracePeriod}
+		for _, col := range header[len(row):] {
+			switch {
+			case strings.HasPrefix(col, colTitle):
+				row = append(row, p.Listings[col[len(colTitle):]].Title)
+			case strings.HasPrefix(col, colDescription):
+				row = append(row, p.Listings[col[len(colDescription):]].Description)
+			case strings.HasPrefix(col, colBenefits):
+				row = append(row, strings.Join(p.Listings[col[len(colBenefits):]].Benefits, benefitSeparator))
+			case strings.HasPrefix(col, colRegionPrice):
+				price, ok := p.Prices[col[len(colRegionPrice):]]
+				if !ok {
+					row = append(row, "")
+					continue
+				}
+				row = append(row, formatPrice(&price))
+			}
+		}
+		rows[i] = row
+	}
+	return header, rows
+}
+
+//FromCSV parses the products from a table in the format written by ToCSV
+func FromCSV(t *bulk.Table) ([]*androidpublisher.InAppProduct, error) {
+	if t.Column(colSku) < 0 {
+		return nil, errors.New("missing sku column")
+	}
+	var products []*androidpublisher.InAppProduct
+	for i, row := range t.Rows {
+		p := &androidpublisher.InAppProduct{}
+		for c, col := range t.Header {
+			if c >= len(row) {
+				break
+			}
+			value := strings.TrimSpace(row[c])
+			if value == "" {
+				continue
+			}
+			var err error
+			switch {
+			case col == colSku:
+				p.Sku = value
+			case col == colStatus:
+				p.Status = value
+			case col == colPurchaseType:
+				p.PurchaseType = value
+			case col == colDefaultLanguage:
+				p.DefaultLanguage = value
+			case col == colPrice:
+				p.DefaultPrice, err = parsePrice(value)
+			case col == colSubscriptionPeriod:
+				p.SubscriptionPeriod = value
+			case col == colTrialPeriod:
+				p.TrialPeriod = value
+			case col == colGracePeriod:
+				p.GracePeriod = value
+			case strings.HasPrefix(col, colTitle):
+				setListing(p, col[len(colTitle):], func(l *androidpublisher.InAppProductListing) { l.Title = value })
+			case strings.HasPrefix(col, colDescription):
+				setListing(p, col[len(colDescription):], func(l *androidpublisher.InAppProductListing) { l.Description = value })
+			case strings.HasPrefix(col, colBenefits):
+				setListing(p, col[len(colBenefits):], func(l *androidpublisher.InAppProductListing) {
+					l.Benefits = strings.Split(value, benefitSeparator)
+				})
+			case strings.HasPrefix(col, colRegionPrice):
+				var price *androidpublisher.Price
+				if price, err = parsePrice(value); err == nil {
+					if p.Prices == nil {
+						p.Prices = map[string]androidpublisher.Price{}
+					}
+					p.Prices[col[len(colRegionPrice):]] = *price
+				}
+			default:
+				err = errors.Errorf("unknown column %v", col)
+			}
+			if err != nil {
+				return nil, errors.Wrapf(err, "row %v", i+2)
+			}
+		}
+		if p.Sku == "" {
+			return nil, errors.Errorf("row %v: missing sku", i+2)
+		}
+		products = append(products, p)
+	}
+	return products, nil
+}
+
+func setListing(p *androidpublisher.InAppProduct, lang string, fn func(*androidpublisher.InAppProductListing)) {
+	if p.Listings == nil {
+		p.Listings = map[string]androidpublisher.InAppProductListing{}
+	}
+	l := p.Listings[lang]
+	fn(&l)
+	p.Listings[lang] = l
+}
+
+//formatPrice formats a price as amount and currency, e.g. "0.99 USD"
+func formatPrice(p *androidpublisher.Price) string {
+	if p == nil || p.PriceMicros == "" {
+		return ""
+	}
+	micros := strings.TrimLeft(p.PriceMicros, "0")
+	for len(micros) < 7 {
+		micros = "0" + micros
+	}
+	units, fraction := micros[:len(micros)-6], strings.TrimRight(micros[len(micros)-6:], "0")
+	for len(fraction) < 2 {
+		fraction += "0"
+	}
+	return strings.TrimSpace(units + "." + fraction + " " + p.Currency)
+}
+
+//parsePrice parses a price formatted by formatPrice
+func parsePrice(s string) (*androidpublisher.Price, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return nil, errors.Errorf("invalid price %q, expected amount and currency, e.g. 0.99 USD", s)
+	}
+	amount := fields[0]
+	units, fraction := amount, ""
+	if i := strings.Index(amount, "."); i >= 0 {
+		units, fraction = amount[:i], amount[i+1:]
+	}
+	if len(fraction) > 6 || strings.Trim(units+fraction, "0123456789") != "" || units+fraction == "" {
+		return nil, errors.Errorf("invalid price %q", s)
+	}
+	micros := strings.TrimLeft(units+fraction+strings.Repeat("0", 6-len(fraction)), "0")
+	if micros == "" {
+		micros = "0"
+	}
+	return &androidpublisher.Price{PriceMicros: micros, Currency: strings.ToUpper(fields[1])}, nil
+}
+
+//Update is a product whose local version differs from the live one
+type Update struct {
+	Product *androidpublisher.InAppProduct `json:"-"`
+	Sku     string                         `json:"sku"`
+	Changes []string                       `json:"changes"`
+}
+
+//Plan lists the changes needed to make the live catalog match the local one
+type Plan struct {
+	Inserts []*androidpublisher.InAppProduct `json:"inserts,omitempty"`
+	Updates []Update                         `json:"updates,omitempty"`
+	Deletes []string                         `json:"deletes,omitempty"`
+}
+
+//Len returns the number of changes
+func (p *Plan) Len() int {
+	return len(p.Inserts) + len(p.Updates) + len(p.Deletes)
+}
+
+//Compare plans the inserts and updates that make live match local. Products that are only live are deleted
+//when deleteMissing is set.
+func Compare(local, live []*androidpublisher.InAppProduct, deleteMissing bool) (*Plan, error) {
+	plan := &Plan{}
+	bySku := map[string]*androidpublisher.InAppProduct{}
+	for _, p := range live {
+		bySku[p.Sku] = p
+	}
+	seen := map[string]bool{}
+	for _, p := range local {
+		if seen[p.Sku] {
+			return nil, errors.Errorf("duplicate sku %v", p.Sku)
+		}
+		seen[p.Sku] = true
+		current, ok := bySku[p.Sku]
+		if !ok {
+			plan.Inserts = append(plan.Inserts, p)
+			continue
+		}
+		changes, err := compare(current, p)
+		if err != nil {
+			return nil, err
+		}
+		if len(changes) > 0 {
+			plan.Updates = append(plan.Updates, Update{Product: p, Sku: p.Sku, Changes: changes})
+		}
+	}
+	if deleteMissing {
+		for _, p := range live {
+			if !seen[p.Sku] {
+				plan.Deletes = append(plan.Deletes, p.Sku)
+			}
+		}
+	}
+	return plan, nil
+}
+
+//compare describes the differences between two versions of a product, e.g. `.prices.DE.priceMicros: "990000" → "1090000"`
+func compare(old, new *androidpublisher.InAppProduct) ([]string, error) {
+	o, n := *old, *new
+	o.PackageName, n.PackageName = "", ""
+	o.ServerResponse = n.ServerResponse
+	ov, err := diff.Normalize(o)
+	if err != nil {
+		return nil, err
+	}
+	nv, err := diff.Normalize(n)
+	if err != nil {
+		return nil, err
+	}
+	var changes []string
+	for _, c := range diff.Compare(ov, nv) {
+		old, _ := json.Marshal(c.Old)
+		new, _ := json.Marshal(c.New)
+		switch c.Kind {
+		case diff.Added:
+			changes = append(changes, c.Path+": + "+string(new))
+		case diff.Removed:
+			changes = append(changes, c.Path+": - "+string(old))
+		default:
+			changes = append(changes, c.Path+": "+string(old)+" → "+string(new))
+		}
+	}
+	return changes, nil
+}
+
+func sorted(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,101 @@
+package catalog
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	androidpublisher "google.golang.org/api/androidpublisher/v3"
+)
+
+func products() []*androidpublisher.InAppProduct {
+	return []*androidpublisher.InAppProduct{
+		{
+			Sku:             "coins_100",
+			Status:          "active",
+			PurchaseType:    "managedUser",
+			DefaultLanguage: "en-US",
+			DefaultPrice:    &androidpublisher.Price{PriceMicros: "990000", Currency: "USD"},
+			Listings: map[string]androidpublisher.InAppProductListing{
+				"en-US": {Title: "100 coins", Description: "A pile of coins"},
+				"de-DE": {Title: "100 Münzen", Description: "Ein Haufen Münzen"},
+			},
+			Prices: map[string]androidpublisher.Price{
+				"US": {PriceMicros: "990000", Currency: "USD"},
+				"DE": {PriceMicros: "1090000", Currency: "EUR"},
+			},
+		},
+		{
+			Sku:             "premium",
+			Status:          "inactive",
+			PurchaseType:    "managedUser",
+			DefaultLanguage: "en-US",
+			DefaultPrice:    &androidpublisher.Price{PriceMicros: "12000000", Currency: "USD"},
+			Listings: map[string]androidpublisher.InAppProductListing{
+				"en-US": {Title: "Premium", Description: "Everything"},
+			},
+		},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"catalog.csv", "catalog.json"} {
+		path := filepath.Join(dir, name)
+		if err := Write(path, products()); err != nil {
+			t.Fatal(err)
+		}
+		read, err := Read(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(read, products()) {
+			t.Errorf("%v: read %+v", name, read)
+		}
+	}
+}
+
+func TestPrice(t *testing.T) {
+	for micros, s := range map[string]string{"990000": "0.99 USD", "12000000": "12.00 USD", "1234567": "1.234567 USD", "0": "0.00 USD"} {
+		if got := formatPrice(&androidpublisher.Price{PriceMicros: micros, Currency: "USD"}); got != s {
+			t.Errorf("formatPrice(%v) = %v, want %v", micros, got, s)
+		}
+		p, err := parsePrice(s)
+		if err != nil || p.PriceMicros != micros {
+			t.Errorf("parsePrice(%v) = %v, %v", s, p, err)
+		}
+	}
+	for _, s := range []string{"0.99", "abc USD", "1.2345678 USD"} {
+		if _, err := parsePrice(s); err == nil {
+			t.Errorf("parsePrice(%v) should fail", s)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	live := products()
+	live[0].PackageName = "com.example"
+	local := products()[:1]
+	local[0].Prices["DE"] = androidpublisher.Price{PriceMicros: "1190000", Currency: "EUR"}
+	local = append(local, &androidpublisher.InAppProduct{Sku: "coins_500"})
+
+	plan, err := Compare(local, live, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Inserts) != 1 || plan.Inserts[0].Sku != "coins_500" || len(plan.Deletes) != 0 {
+		t.Errorf("unexpected plan %+v", plan)
+	}
+	want := []string{`.prices.DE.priceMicros: "1090000" → "1190000"`}
+	if len(plan.Updates) != 1 || !reflect.DeepEqual(plan.Updates[0].Changes, want) {
+		t.Errorf("unexpected updates %+v", plan.Updates)
+	}
+
+	plan, _ = Compare(local, live, true)
+	if !reflect.DeepEqual(plan.Deletes, []string{"premium"}) || plan.Len() != 3 {
+		t.Errorf("unexpected deletes %v", plan.Deletes)
+	}
+	if _, err := Compare(append(local, local[0]), live, false); err == nil {
+		t.Error("expected error for duplicate sku")
+	}
+}
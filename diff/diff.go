@@ -0,0 +1,224 @@
+//Package diff compares two API responses structurally, key by key, instead of line by line
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/logrusorgru/aurora"
+)
+
+//Kind of a change
+type Kind int
+
+//Kinds of changes between two documents
+const (
+	Added Kind = iota
+	Removed
+	Changed
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	}
+	return "changed"
+}
+
+//Change is a difference found at Path, e.g. `.inappproduct[0].status`
+type Change struct {
+	Path     string
+	Kind     Kind
+	Old, New interface{}
+}
+
+//Side of the diff a document is rendered on
+type Side int
+
+//Sides of a diff
+const (
+	Left Side = iota
+	Right
+)
+
+//Normalize converts v to the generic form it has in JSON so that responses of any type can be compared
+func Normalize(v interface{}) (interface{}, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var doc interface{}
+	err = json.Unmarshal(body, &doc)
+	return doc, err
+}
+
+//Compare returns the changes needed to turn old into new. Both values must be normalized.
+func Compare(old, new interface{}) []Change {
+	var changes []Change
+	compare("", old, new, &changes)
+	return changes
+}
+
+func compare(path string, old, new interface{}, changes *[]Change) {
+	switch o := old.(type) {
+	case map[string]interface{}:
+		n, ok := new.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := map[string]bool{}
+		for k := range o {
+			keys[k] = true
+		}
+		for k := range n {
+			keys[k] = true
+		}
+		for _, k := range sortedKeys(keys) {
+			p := path + "." + k
+			ov, inOld := o[k]
+			nv, inNew := n[k]
+			switch {
+			case !inNew:
+				*changes = append(*changes, Change{Path: p, Kind: Removed, Old: ov})
+			case !inOld:
+				*changes = append(*changes, Change{Path: p, Kind: Added, New: nv})
+			default:
+				compare(p, ov, nv, changes)
+			}
+		}
+		return
+	case []interface{}:
+		n, ok := new.([]interface{})
+		if !ok {
+			break
+		}
+		for i := 0; i < len(o) || i < len(n); i++ {
+			p := fmt.Sprintf("%v[%v]", path, i)
+			switch {
+			case i >= len(n):
+				*changes = append(*changes, Change{Path: p, Kind: Removed, Old: o[i]})
+			case i >= len(o):
+				*changes = append(*changes, Change{Path: p, Kind: Added, New: n[i]})
+			default:
+				compare(p, o[i], n[i], changes)
+			}
+		}
+		return
+	}
+	if !reflect.DeepEqual(old, new) {
+		if path == "" {
+			path = "."
+		}
+		*changes = append(*changes, Change{Path: path, Kind: Changed, Old: old, New: new})
+	}
+}
+
+//Summary returns a short description of the changes, e.g. "1 added, 2 changed"
+func Summary(changes []Change) string {
+	if len(changes) == 0 {
+		return "no differences"
+	}
+	counts := map[Kind]int{}
+	for _, c := range changes {
+		counts[c.Kind]++
+	}
+	var parts []string
+	for _, k := range []Kind{Added, Removed, Changed} {
+		if counts[k] > 0 {
+			parts = append(parts, fmt.Sprintf("%v %v", counts[k], k))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+//Render pretty prints a normalized document, colouring the lines affected by changes.
+//The left side shows removed keys, the right side shows added keys and both show changed ones.
+func Render(v interface{}, changes []Change, side Side) string {
+	kinds := map[string]Kind{}
+	for _, c := range changes {
+		if (c.Kind == Added && side == Left) || (c.Kind == Removed && side == Right) {
+			continue
+		}
+		kinds[c.Path] = c.Kind
+	}
+	var lines []string
+	render(&lines, v, "", "", "", false, kinds, nil)
+	return strings.Join(lines, "\n")
+}
+
+func render(lines *[]string, v interface{}, path, indent, key string, comma bool, kinds map[string]Kind, inherited *Kind) {
+	kind := inherited
+	if k, ok := kinds[path]; ok {
+		kind = &k
+	}
+	if kind == nil && path == "" {
+		if k, ok := kinds["."]; ok {
+			kind = &k
+		}
+	}
+	suffix := ""
+	if comma {
+		suffix = ","
+	}
+	line := func(s string) {
+		*lines = append(*lines, colour(indent+s, kind))
+	}
+	switch o := v.(type) {
+	case map[string]interface{}:
+		if len(o) == 0 {
+			line(key + "{}" + suffix)
+			return
+		}
+		line(key + "{")
+		keys := map[string]bool{}
+		for k := range o {
+			keys[k] = true
+		}
+		sorted := sortedKeys(keys)
+		for i, k := range sorted {
+			render(lines, o[k], path+"."+k, indent+" ", fmt.Sprintf("%q: ", k), i < len(sorted)-1, kinds, kind)
+		}
+		line("}" + suffix)
+	case []interface{}:
+		if len(o) == 0 {
+			line(key + "[]" + suffix)
+			return
+		}
+		line(key + "[")
+		for i, item := range o {
+			render(lines, item, fmt.Sprintf("%v[%v]", path, i), indent+" ", "", i < len(o)-1, kinds, kind)
+		}
+		line("]" + suffix)
+	default:
+		body, _ := json.Marshal(o)
+		line(key + string(body) + suffix)
+	}
+}
+
+func colour(s string, kind *Kind) string {
+	if kind == nil {
+		return s
+	}
+	switch *kind {
+	case Added:
+		return aurora.Green(s).String()
+	case Removed:
+		return aurora.Red(s).String()
+	}
+	return aurora.Brown(s).String()
+}
+
+func sortedKeys(keys map[string]bool) []string {
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
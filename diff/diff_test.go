@@ -0,0 +1,48 @@
+package diff
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	old, _ := Normalize(map[string]interface{}{
+		"expiryTimeMillis": "100",
+		"autoRenewing":     true,
+		"items":            []int{1, 2, 3},
+	})
+	new, _ := Normalize(map[string]interface{}{
+		"expiryTimeMillis": "200",
+		"items":            []int{1, 2},
+		"cancelReason":     0,
+	})
+	got := Compare(old, new)
+	want := []Change{
+		{Path: ".autoRenewing", Kind: Removed, Old: true},
+		{Path: ".cancelReason", Kind: Added, New: float64(0)},
+		{Path: ".expiryTimeMillis", Kind: Changed, Old: "100", New: "200"},
+		{Path: ".items[2]", Kind: Removed, Old: float64(3)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Compare() = %#v, want %#v", got, want)
+	}
+	if s := Summary(got); s != "1 added, 2 removed, 1 changed" {
+		t.Errorf("Summary() = %q", s)
+	}
+	if s := Summary(Compare(old, old)); s != "no differences" {
+		t.Errorf("Summary() = %q", s)
+	}
+
+	left := strings.Split(Render(old, got, Left), "\n")
+	right := strings.Split(Render(new, got, Right), "\n")
+	if len(left) != 9 || len(right) != 8 {
+		t.Fatalf("unexpected rendering:\n%v\n\n%v", strings.Join(left, "\n"), strings.Join(right, "\n"))
+	}
+	if !strings.Contains(left[1], "\x1b[") || !strings.Contains(left[6], "\x1b[") || strings.Contains(left[3], "\x1b[") {
+		t.Errorf("left side colours wrong lines:\n%q", left)
+	}
+	if !strings.Contains(right[1], "\x1b[") || !strings.Contains(right[2], "\x1b[") || strings.Contains(right[3], "\x1b[") {
+		t.Errorf("right side colours wrong lines:\n%q", right)
+	}
+}
@@ -0,0 +1,375 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hassansin/androidpublisher/images"
+	"github.com/hassansin/androidpublisher/ui"
+	"github.com/pkg/errors"
+	androidpublisher "google.golang.org/api/androidpublisher/v3"
+	"google.golang.org/api/googleapi"
+)
+
+//editState is the edit the Edits operations work on unless an EditID is given, and the last bundle uploaded to it
+var editState struct {
+	sync.Mutex
+	current    string
+	bundleEdit string
+	bundle     int64
+}
+
+//setEdit makes id the current edit
+func setEdit(id string) {
+	editState.Lock()
+	defer editState.Unlock()
+	editState.current = id
+}
+
+//closeEdit forgets a committed or deleted edit, as the current edit and as the edit of the last bundle
+func closeEdit(id string) {
+	editState.Lock()
+	defer editState.Unlock()
+	if editState.current == id {
+		editState.current = ""
+	}
+	if editState.bundleEdit == id {
+		editState.bundleEdit, editState.bundle = "", 0
+	}
+}
+
+//editID returns the edit given by a parameter or the current edit
+func editID(param *Param) (string, error) {
+	if param.Value != "" {
+		return param.Value, nil
+	}
+	editState.Lock()
+	defer editState.Unlock()
+	if editState.current == "" {
+		return "", errors.New("no edit is open, create one with Edits.Insert or give an EditID")
+	}
+	return editState.current, nil
+}
+
+//deobfuscationFileTypes are the accepted file types and their API names
+var deobfuscationFileTypes = map[string]string{"proguard": "proguard", "nativecode": "nativeCode"}
+
+func deobfuscationFileType(s string) (string, error) {
+	if t, ok := deobfuscationFileTypes[strings.ToLower(s)]; ok {
+		return t, nil
+	}
+	return "", errors.Errorf("invalid file type %q, expected proguard or nativeCode", s)
+}
+
+//uploadDeobfuscationFile uploads a ProGuard/R8 mapping or native debug symbols for an APK or bundle of an edit
+func uploadDeobfuscationFile(ctx context.Context, service *androidpublisher.Service, pkgName, edit string, versionCode int64, fileType, path string) (interface{}, error) {
+	fileType, err := deobfuscationFileType(fileType)
+	if err != nil {
+		return nil, err
+	}
+	f, progress, err := openUpload(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	s := androidpublisher.NewEditsDeobfuscationfilesService(service)
+	return s.Upload(pkgName, edit, versionCode, fileType).Media(f, uploadMedia).ProgressUpdater(progress).Context(ctx).Do()
+}
+
+//initEditOperations adds the Edits groups
+func initEditOperations(service *androidpublisher.Service, pkgName string) {
+	editParam := func() *Param { return &Param{Name: "EditID(default: current edit)"} }
+
+	grp := &Group{Name: "Edits"}
+	groups = append(groups, grp)
+	grp.Operations = append(grp.Operations, &Operation{
+		Name: "Insert",
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
+			edit, err := androidpublisher.NewEditsService(service).Insert(pkgName, &androidpublisher.AppEdit{}).Context(ctx).Do()
+			if err != nil {
+				return nil, err
+			}
+			setEdit(edit.Id)
+			return edit, nil
+		},
+	})
+	grp.Operations = append(grp.Operations, &Operation{
+		Name:   "Get",
+		Params: []*Param{editParam()},
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
+			id, err := editID(params[0])
+			if err != nil {
+				return nil, err
+			}
+			return androidpublisher.NewEditsService(service).Get(pkgName, id).Context(ctx).Do()
+		},
+	})
+	grp.Operations = append(grp.Operations, &Operation{
+		Name:   "Validate",
+		Params: []*Param{editParam()},
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
+			id, err := editID(params[0])
+			if err != nil {
+				return nil, err
+			}
+			return androidpublisher.NewEditsService(service).Validate(pkgName, id).Context(ctx).Do()
+		},
+	})
+	grp.Operations = append(grp.Operations, &Operation{
+		Name:   "Commit",
+		Params: []*Param{editParam()},
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
+			id, err := editID(params[0])
+			if err != nil {
+				return nil, err
+			}
+			edit, err := androidpublisher.NewEditsService(service).Commit(pkgName, id).Context(ctx).Do()
+			if err == nil {
+				closeEdit(id)
+			}
+			return edit, err
+		},
+	})
+	grp.Operations = append(grp.Operations, &Operation{
+		Name:   "Delete",
+		Params: []*Param{editParam()},
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
+			id, err := editID(params[0])
+			if err != nil {
+				return nil, err
+			}
+			err = androidpublisher.NewEditsService(service).Delete(pkgName, id).Context(ctx).Do()
+			if err == nil {
+				closeEdit(id)
+			}
+			return nil, err
+		},
+	})
+
+	grp = &Group{Name: "Edits.bundles"}
+	groups = append(groups, grp)
+	grp.Operations = append(grp.Operations, &Operation{
+		Name:   "List",
+		Params: []*Param{editParam()},
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
+			id, err := editID(params[0])
+			if err != nil {
+				return nil, err
+			}
+			return androidpublisher.NewEditsBundlesService(service).List(pkgName, id).Context(ctx).Do()
+		},
+	})
+	grp.Operations = append(grp.Operations, &Operation{
+		Name:    "Upload",
+		Params:  []*Param{editParam(), {Name: "File", Required: true}},
+		Timeout: bulkTimeout,
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
+			id, err := editID(params[0])
+			if err != nil {
+				return nil, err
+			}
+			f, progress, err := openUpload(ctx, params[1].Value)
+			if err != nil {
+				return nil, err
+			}
+			defer f.Close()
+			s := androidpublisher.NewEditsBundlesService(service)
+			bundle, err := s.Upload(pkgName, id).Media(f, uploadMedia).ProgressUpdater(progress).Context(ctx).Do()
+			if err != nil {
+				return nil, err
+			}
+			editState.Lock()
+			editState.bundleEdit, editState.bundle = id, bundle.VersionCode
+			editState.Unlock()
+			return bundle, nil
+		},
+	})
+
+	grp = &Group{Name: "Edits.deobfuscationfiles"}
+	groups = append(groups, grp)
+	grp.Operations = append(grp.Operations, &Operation{
+		Name: "Upload",
+		Params: []*Param{
+			editParam(), {Name: "VersionCode", Required: true},
+			{Name: "Type(proguard/nativeCode)", Required: true}, {Name: "File", Required: true},
+		},
+		Timeout: bulkTimeout,
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
+			id, err := editID(params[0])
+			if err != nil {
+				return nil, err
+			}
+			versionCode, err := strconv.ParseInt(params[1].Value, 10, 64)
+			if err != nil {
+				return nil, errors.Errorf("invalid version code %q", params[1].Value)
+			}
+			return uploadDeobfuscationFile(ctx, service, pkgName, id, versionCode, params[2].Value, params[3].Value)
+		},
+	})
+	grp.Operations = append(grp.Operations, &Operation{
+		Name:    "UploadForLastBundle",
+		Params:  []*Param{{Name: "Type(proguard/nativeCode)", Required: true}, {Name: "File", Required: true}},
+		Timeout: bulkTimeout,
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
+			editState.Lock()
+			id, versionCode := editState.bundleEdit, editState.bundle
+			editState.Unlock()
+			if id == "" {
+				return nil, errors.New("no bundle has been uploaded to an open edit, upload one with Edits.bundles.Upload")
+			}
+			return uploadDeobfuscationFile(ctx, service, pkgName, id, versionCode, params[0].Value, params[1].Value)
+		},
+	})
+
+	initImageOperations(service, pkgName, editParam)
+}
+
+//imageType checks an image type parameter
+func imageType(param *Param) (string, error) {
+	if _, ok := images.Specs[param.Value]; !ok {
+		return "", errors.Errorf("unknown image type %q, expected one of %v", param.Value, strings.Join(images.Types(), ", "))
+	}
+	return param.Value, nil
+}
+
+//initImageOperations adds the Edits.images group. Uploads are checked locally first, since a file the Play Store
+//doesn't accept is otherwise only reported when the edit is validated or committed.
+func initImageOperations(service *androidpublisher.Service, pkgName string, editParam func() *Param) {
+	imageTypeParam := func(required bool) *Param {
+		name := "ImageType(" + strings.Join(images.Types(), "/") + ")"
+		if !required {
+			name = "ImageType(default: all)"
+		}
+		return &Param{Name: name, Required: required}
+	}
+	grp := &Group{Name: "Edits.images"}
+	groups = append(groups, grp)
+	grp.Operations = append(grp.Operations, &Operation{
+		Name:   "List",
+		Params: []*Param{editParam(), {Name: "Language", Required: true}, imageTypeParam(false)},
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
+			id, err := editID(params[0])
+			if err != nil {
+				return nil, err
+			}
+			types := images.Types()
+			if params[2].Value != "" {
+				t, err := imageType(params[2])
+				if err != nil {
+					return nil, err
+				}
+				types = []string{t}
+			}
+			s := androidpublisher.NewEditsImagesService(service)
+			var b strings.Builder
+			for _, t := range types {
+				res, err := s.List(pkgName, id, params[1].Value, t).Context(ctx).Do()
+				if err != nil {
+					return nil, errors.Wrap(err, t)
+				}
+				if len(res.Images) == 0 && len(types) > 1 {
+					continue
+				}
+				fmt.Fprintf(&b, "%v (%v of %v)\n%v\n", t, len(res.Images), images.Specs[t].MaxCount, images.Table(res.Images))
+			}
+			if b.Len() == 0 {
+				return ui.Text("No images"), nil
+			}
+			return ui.Text(b.String()), nil
+		},
+	})
+	grp.Operations = append(grp.Operations, &Operation{
+		Name:    "Upload",
+		Params:  []*Param{editParam(), {Name: "Language", Required: true}, imageTypeParam(true), {Name: "Files(comma separated, or a directory)", Required: true}},
+		Timeout: bulkTimeout,
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
+			id, err := editID(params[0])
+			if err != nil {
+				return nil, err
+			}
+			t, err := imageType(params[2])
+			if err != nil {
+				return nil, err
+			}
+			files, err := images.Files(params[3].Value)
+			if err != nil {
+				return nil, err
+			}
+			if len(files) == 0 {
+				return nil, errors.Errorf("no PNG or JPEG files in %v", params[3].Value)
+			}
+			language := params[1].Value
+			s := androidpublisher.NewEditsImagesService(service)
+			existing, err := s.List(pkgName, id, language, t).Context(ctx).Do()
+			if err != nil {
+				return nil, err
+			}
+			infos, err := images.Validate(t, files, len(existing.Images))
+			if err != nil {
+				return nil, err
+			}
+			var uploaded []*androidpublisher.Image
+			for i, info := range infos {
+				img, err := uploadImage(ctx, s, pkgName, id, language, t, info)
+				if err != nil {
+					return nil, errors.Wrapf(err, "%v uploaded", len(uploaded))
+				}
+				uploaded = append(uploaded, img)
+				reportProgress(ctx, Progress{Done: i + 1, Total: len(infos)})
+			}
+			return ui.Text(fmt.Sprintf("Uploaded %v %v images for %v\n\n%v", len(uploaded), t, language, images.Table(uploaded))), nil
+		},
+	})
+	grp.Operations = append(grp.Operations, &Operation{
+		Name:   "Delete",
+		Params: []*Param{editParam(), {Name: "Language", Required: true}, imageTypeParam(true), {Name: "ImageId", Required: true}},
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
+			id, err := editID(params[0])
+			if err != nil {
+				return nil, err
+			}
+			t, err := imageType(params[2])
+			if err != nil {
+				return nil, err
+			}
+			return nil, androidpublisher.NewEditsImagesService(service).Delete(pkgName, id, params[1].Value, t, params[3].Value).Context(ctx).Do()
+		},
+	})
+	grp.Operations = append(grp.Operations, &Operation{
+		Name:   "DeleteAll",
+		Params: []*Param{editParam(), {Name: "Language", Required: true}, imageTypeParam(true)},
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
+			id, err := editID(params[0])
+			if err != nil {
+				return nil, err
+			}
+			t, err := imageType(params[2])
+			if err != nil {
+				return nil, err
+			}
+			res, err := androidpublisher.NewEditsImagesService(service).Deleteall(pkgName, id, params[1].Value, t).Context(ctx).Do()
+			if err != nil {
+				return nil, err
+			}
+			return ui.Text(fmt.Sprintf("Deleted %v images\n\n%v", len(res.Deleted), images.Table(res.Deleted))), nil
+		},
+	})
+}
+
+//uploadImage uploads a validated file
+func uploadImage(ctx context.Context, s *androidpublisher.EditsImagesService, pkgName, edit, language, imageType string, info images.Info) (*androidpublisher.Image, error) {
+	f, err := os.Open(info.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	res, err := s.Upload(pkgName, edit, language, imageType).Media(f, googleapi.ContentType("image/"+info.Format)).Context(ctx).Do()
+	if err != nil {
+		return nil, errors.Wrapf(err, "uploading %v", info.Path)
+	}
+	return res.Image, nil
+}
@@ -0,0 +1,18 @@
+//Package enums names the numeric enum fields of the purchase resources
+package enums
+
+import "strconv"
+
+//Name returns the name of v, where names lists the names of 0, 1, ... in order. Values without a name are
+//returned as the number.
+func Name(v int64, names ...string) string {
+	if v >= 0 && int(v) < len(names) {
+		return names[v]
+	}
+	return strconv.FormatInt(v, 10)
+}
+
+//PurchaseState names the purchaseState of a product purchase
+func PurchaseState(v int64) string {
+	return Name(v, "purchased", "cancelled", "pending")
+}
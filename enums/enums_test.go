@@ -0,0 +1,23 @@
+package enums
+
+import "testing"
+
+func TestName(t *testing.T) {
+	tests := []struct {
+		v    int64
+		want string
+	}{
+		{0, "purchased"},
+		{2, "pending"},
+		{3, "3"},
+		{-1, "-1"},
+	}
+	for _, tt := range tests {
+		if got := PurchaseState(tt.v); got != tt.want {
+			t.Errorf("PurchaseState(%v) = %q, want %q", tt.v, got, tt.want)
+		}
+	}
+	if got := Name(1, "pending", "acknowledged"); got != "acknowledged" {
+		t.Errorf("Name(1) = %q", got)
+	}
+}
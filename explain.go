@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hassansin/androidpublisher/apierror"
+	"github.com/hassansin/androidpublisher/orders"
+	"github.com/hassansin/androidpublisher/subscription"
+	"github.com/hassansin/androidpublisher/ui"
+	"github.com/logrusorgru/aurora"
+	androidpublisher "google.golang.org/api/androidpublisher/v3"
+)
+
+//maxLinkedPurchases limits how far the upgrade/downgrade chain is followed
+const maxLinkedPurchases = 10
+
+//explainSubscriptionOperation explains a subscription purchase in words and follows linkedPurchaseToken to the
+//purchases it replaced
+func explainSubscriptionOperation(service *androidpublisher.Service, pkgName string) *Operation {
+	return &Operation{
+		Name:   "Explain",
+		Params: []*Param{{Name: "SubscriptionId", Required: true}, {Name: "Token", Required: true}},
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
+			s := androidpublisher.NewPurchasesSubscriptionsService(service)
+			id, token := params[0].Value, params[1].Value
+			sub, err := s.Get(pkgName, id, token).Context(ctx).Do()
+			if err != nil {
+				return nil, err
+			}
+			now := time.Now()
+			var b strings.Builder
+			fmt.Fprintf(&b, "%v %v\n%v\n", aurora.Bold("Subscription"), id, subscription.Explain(sub, now))
+			if err := recordOrders(orders.Purchase{OrderID: sub.OrderId, Kind: orders.Subscription, ProductID: id, Token: token, Source: "Purchases.subscriptions.Explain"}); err != nil {
+				return nil, err
+			}
+
+			for i := 0; sub.LinkedPurchaseToken != "" && i < maxLinkedPurchases; i++ {
+				linked := sub.LinkedPurchaseToken
+				//an upgrade or downgrade usually changes the subscription id, which may be known from the order index
+				if p, ok, err := findToken(linked); err != nil {
+					return nil, err
+				} else if ok {
+					id = p.ProductID
+				}
+				if sub, err = s.Get(pkgName, id, linked).Context(ctx).Do(); err != nil {
+					fmt.Fprintf(&b, "\n%v %v\nUnable to get it as %v: %v\n", aurora.Bold("Replaced purchase"), linked, id, apierror.Summary(err))
+					break
+				}
+				fmt.Fprintf(&b, "\n%v %v, token %v\n%v\n", aurora.Bold("Replaced subscription"), id, linked, subscription.Explain(sub, now))
+				if err := recordOrders(orders.Purchase{OrderID: sub.OrderId, Kind: orders.Subscription, ProductID: id, Token: linked, Source: "Purchases.subscriptions.Explain"}); err != nil {
+					return nil, err
+				}
+			}
+			return ui.Text(b.String()), nil
+		},
+	}
+}
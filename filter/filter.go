@@ -0,0 +1,580 @@
+//Package filter implements a small subset of the jq language that is used to
+//narrow down API responses, e.g. `.inappproduct[].sku` or
+//`.voidedPurchases[] | select(.voidedSource == 1)`
+package filter
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+//Filter is a parsed filter expression
+type Filter struct {
+	expr  string
+	stage stage
+}
+
+type stage interface {
+	eval(v interface{}) ([]interface{}, error)
+}
+
+//Parse compiles a filter expression
+func Parse(expr string) (*Filter, error) {
+	p := &parser{src: expr}
+	s, err := p.pipeline()
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid filter %q", expr)
+	}
+	p.skipSpace()
+	if !p.eof() {
+		return nil, errors.Errorf("invalid filter %q: unexpected %q at %v", expr, p.src[p.pos:], p.pos)
+	}
+	return &Filter{expr: expr, stage: s}, nil
+}
+
+//String returns the source expression
+func (f *Filter) String() string {
+	return f.expr
+}
+
+//Apply runs the filter against v and returns every value it produces.
+//v is converted to its JSON representation first, so field names are the
+//ones seen in the response and not the Go struct names.
+func (f *Filter) Apply(v interface{}) ([]interface{}, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+	return f.stage.eval(doc)
+}
+
+type identity struct{}
+
+func (identity) eval(v interface{}) ([]interface{}, error) {
+	return []interface{}{v}, nil
+}
+
+type field string
+
+func (f field) eval(v interface{}) ([]interface{}, error) {
+	switch o := v.(type) {
+	case nil:
+		return []interface{}{nil}, nil
+	case map[string]interface{}:
+		return []interface{}{o[string(f)]}, nil
+	}
+	return nil, errors.Errorf("cannot index %v with %q", typeName(v), string(f))
+}
+
+type index int
+
+func (i index) eval(v interface{}) ([]interface{}, error) {
+	switch a := v.(type) {
+	case nil:
+		return []interface{}{nil}, nil
+	case []interface{}:
+		n := int(i)
+		if n < 0 {
+			n += len(a)
+		}
+		if n < 0 || n >= len(a) {
+			return []interface{}{nil}, nil
+		}
+		return []interface{}{a[n]}, nil
+	}
+	return nil, errors.Errorf("cannot index %v with number", typeName(v))
+}
+
+type iterate struct{}
+
+func (iterate) eval(v interface{}) ([]interface{}, error) {
+	switch o := v.(type) {
+	case []interface{}:
+		return o, nil
+	case map[string]interface{}:
+		keys := sortedKeys(o)
+		result := make([]interface{}, len(keys))
+		for i, k := range keys {
+			result[i] = o[k]
+		}
+		return result, nil
+	}
+	return nil, errors.Errorf("cannot iterate over %v", typeName(v))
+}
+
+type pipe []stage
+
+func (p pipe) eval(v interface{}) ([]interface{}, error) {
+	values := []interface{}{v}
+	for _, s := range p {
+		var next []interface{}
+		for _, value := range values {
+			out, err := s.eval(value)
+			if err != nil {
+				return nil, err
+			}
+			next = append(next, out...)
+		}
+		values = next
+	}
+	return values, nil
+}
+
+type literal struct {
+	value interface{}
+}
+
+func (l literal) eval(v interface{}) ([]interface{}, error) {
+	return []interface{}{l.value}, nil
+}
+
+type compare struct {
+	op          string
+	left, right stage
+}
+
+func (c compare) eval(v interface{}) ([]interface{}, error) {
+	lefts, err := c.left.eval(v)
+	if err != nil {
+		return nil, err
+	}
+	rights, err := c.right.eval(v)
+	if err != nil {
+		return nil, err
+	}
+	var result []interface{}
+	for _, l := range lefts {
+		for _, r := range rights {
+			result = append(result, compareValues(c.op, l, r))
+		}
+	}
+	return result, nil
+}
+
+type logical struct {
+	and         bool
+	left, right stage
+}
+
+func (l logical) eval(v interface{}) ([]interface{}, error) {
+	lefts, err := l.left.eval(v)
+	if err != nil {
+		return nil, err
+	}
+	var result []interface{}
+	for _, left := range lefts {
+		if truthy(left) != l.and {
+			result = append(result, !l.and)
+			continue
+		}
+		rights, err := l.right.eval(v)
+		if err != nil {
+			return nil, err
+		}
+		for _, right := range rights {
+			result = append(result, truthy(right))
+		}
+	}
+	return result, nil
+}
+
+type selectStage struct {
+	cond stage
+}
+
+func (s selectStage) eval(v interface{}) ([]interface{}, error) {
+	conds, err := s.cond.eval(v)
+	if err != nil {
+		return nil, err
+	}
+	var result []interface{}
+	for _, c := range conds {
+		if truthy(c) {
+			result = append(result, v)
+		}
+	}
+	return result, nil
+}
+
+type builtin string
+
+func (b builtin) eval(v interface{}) ([]interface{}, error) {
+	switch b {
+	case "length":
+		switch o := v.(type) {
+		case nil:
+			return []interface{}{float64(0)}, nil
+		case string:
+			return []interface{}{float64(len([]rune(o)))}, nil
+		case []interface{}:
+			return []interface{}{float64(len(o))}, nil
+		case map[string]interface{}:
+			return []interface{}{float64(len(o))}, nil
+		}
+	case "keys":
+		if o, ok := v.(map[string]interface{}); ok {
+			var keys []interface{}
+			for _, k := range sortedKeys(o) {
+				keys = append(keys, k)
+			}
+			return []interface{}{keys}, nil
+		}
+	case "not":
+		return []interface{}{!truthy(v)}, nil
+	}
+	return nil, errors.Errorf("%v has no %v", typeName(v), string(b))
+}
+
+func truthy(v interface{}) bool {
+	return v != nil && v != false
+}
+
+func compareValues(op string, l, r interface{}) bool {
+	switch op {
+	case "==":
+		return reflect.DeepEqual(l, r)
+	case "!=":
+		return !reflect.DeepEqual(l, r)
+	}
+	var cmp int
+	ln, lok := l.(float64)
+	rn, rok := r.(float64)
+	ls, lsok := l.(string)
+	rs, rsok := r.(string)
+	switch {
+	case lok && rok:
+		cmp = compareFloats(ln, rn)
+	case lsok && rsok:
+		cmp = strings.Compare(ls, rs)
+	default:
+		return false
+	}
+	switch op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func sortedKeys(o map[string]interface{}) []string {
+	keys := make([]string, 0, len(o))
+	for k := range o {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+type parser struct {
+	src string
+	pos int
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *parser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *parser) skipSpace() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t' || p.peek() == '\n') {
+		p.pos++
+	}
+}
+
+//consume skips whitespace and advances past tok if it's next in the input
+func (p *parser) consume(tok string) bool {
+	p.skipSpace()
+	if strings.HasPrefix(p.src[p.pos:], tok) {
+		p.pos += len(tok)
+		return true
+	}
+	return false
+}
+
+func (p *parser) keyword(word string) bool {
+	p.skipSpace()
+	if !strings.HasPrefix(p.src[p.pos:], word) {
+		return false
+	}
+	end := p.pos + len(word)
+	if end < len(p.src) && isIdent(p.src[end]) {
+		return false
+	}
+	p.pos = end
+	return true
+}
+
+//pipeline := or ('|' or)*
+func (p *parser) pipeline() (stage, error) {
+	s, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	stages := pipe{s}
+	for p.consume("|") {
+		s, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		stages = append(stages, s)
+	}
+	if len(stages) == 1 {
+		return stages[0], nil
+	}
+	return stages, nil
+}
+
+//or := and ('or' and)*
+func (p *parser) or() (stage, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = logical{and: false, left: left, right: right}
+	}
+	return left, nil
+}
+
+//and := comparison ('and' comparison)*
+func (p *parser) and() (stage, error) {
+	left, err := p.comparison()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.comparison()
+		if err != nil {
+			return nil, err
+		}
+		left = logical{and: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+//comparison := term (op term)?
+func (p *parser) comparison() (stage, error) {
+	left, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.consume(op) {
+			right, err := p.term()
+			if err != nil {
+				return nil, err
+			}
+			return compare{op: op, left: left, right: right}, nil
+		}
+	}
+	return left, nil
+}
+
+//term := path | literal | 'select(' pipeline ')' | '(' pipeline ')' | builtin
+func (p *parser) term() (stage, error) {
+	p.skipSpace()
+	switch c := p.peek(); {
+	case c == '.':
+		return p.path()
+	case c == '"':
+		s, err := p.str()
+		if err != nil {
+			return nil, err
+		}
+		return literal{s}, nil
+	case c == '-' || (c >= '0' && c <= '9'):
+		return p.number()
+	case c == '(':
+		p.pos++
+		s, err := p.pipeline()
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume(")") {
+			return nil, errors.Errorf("missing ) at %v", p.pos)
+		}
+		return s, nil
+	}
+	switch {
+	case p.keyword("true"):
+		return literal{true}, nil
+	case p.keyword("false"):
+		return literal{false}, nil
+	case p.keyword("null"):
+		return literal{nil}, nil
+	case p.keyword("length"):
+		return builtin("length"), nil
+	case p.keyword("keys"):
+		return builtin("keys"), nil
+	case p.keyword("not"):
+		return builtin("not"), nil
+	case p.keyword("select"):
+		if !p.consume("(") {
+			return nil, errors.Errorf("expected ( after select at %v", p.pos)
+		}
+		cond, err := p.pipeline()
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume(")") {
+			return nil, errors.Errorf("missing ) at %v", p.pos)
+		}
+		return selectStage{cond}, nil
+	}
+	if p.eof() {
+		return nil, errors.New("unexpected end of filter")
+	}
+	return nil, errors.Errorf("unexpected %q at %v", p.src[p.pos:], p.pos)
+}
+
+//path := '.' | ('.' name | '.'? '[' number? ']')+
+func (p *parser) path() (stage, error) {
+	var steps pipe
+	for {
+		switch {
+		case p.peek() == '.':
+			p.pos++
+			switch c := p.peek(); {
+			case c == '"':
+				name, err := p.str()
+				if err != nil {
+					return nil, err
+				}
+				steps = append(steps, field(name))
+			case isIdent(c):
+				start := p.pos
+				for !p.eof() && isIdent(p.peek()) {
+					p.pos++
+				}
+				steps = append(steps, field(p.src[start:p.pos]))
+			case c == '[':
+			default:
+				if len(steps) > 0 {
+					return nil, errors.Errorf("expected field name at %v", p.pos)
+				}
+			}
+		case p.peek() == '[':
+			p.pos++
+			p.skipSpace()
+			if p.peek() == ']' {
+				p.pos++
+				steps = append(steps, iterate{})
+				continue
+			}
+			start := p.pos
+			if p.peek() == '-' {
+				p.pos++
+			}
+			for !p.eof() && p.peek() >= '0' && p.peek() <= '9' {
+				p.pos++
+			}
+			n, err := strconv.Atoi(p.src[start:p.pos])
+			if err != nil {
+				return nil, errors.Errorf("invalid index at %v", start)
+			}
+			if !p.consume("]") {
+				return nil, errors.Errorf("missing ] at %v", p.pos)
+			}
+			steps = append(steps, index(n))
+		default:
+			switch len(steps) {
+			case 0:
+				return identity{}, nil
+			case 1:
+				return steps[0], nil
+			}
+			return steps, nil
+		}
+	}
+}
+
+func (p *parser) str() (string, error) {
+	start := p.pos
+	p.pos++
+	for !p.eof() {
+		switch p.peek() {
+		case '\\':
+			p.pos += 2
+			continue
+		case '"':
+			p.pos++
+			s, err := strconv.Unquote(p.src[start:p.pos])
+			return s, errors.Wrapf(err, "invalid string at %v", start)
+		}
+		p.pos++
+	}
+	return "", errors.Errorf("unterminated string at %v", start)
+}
+
+func (p *parser) number() (stage, error) {
+	start := p.pos
+	if p.peek() == '-' {
+		p.pos++
+	}
+	for !p.eof() && (p.peek() == '.' || (p.peek() >= '0' && p.peek() <= '9')) {
+		p.pos++
+	}
+	n, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+	if err != nil {
+		return nil, errors.Errorf("invalid number at %v", start)
+	}
+	return literal{n}, nil
+}
+
+func isIdent(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
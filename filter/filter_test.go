@@ -0,0 +1,69 @@
+package filter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	doc := map[string]interface{}{
+		"inappproduct": []interface{}{
+			map[string]interface{}{"sku": "coins_100", "status": "active", "price": 1},
+			map[string]interface{}{"sku": "coins_500", "status": "inactive", "price": 4},
+			map[string]interface{}{"sku": "gems_10", "status": "active", "price": 2},
+		},
+		"tokenPagination": map[string]interface{}{"nextPageToken": "abc"},
+	}
+	cases := []struct {
+		expr string
+		want []interface{}
+	}{
+		{". | length", []interface{}{float64(2)}},
+		{".tokenPagination.nextPageToken", []interface{}{"abc"}},
+		{`."tokenPagination"["nextPageToken"]`, nil},
+		{".inappproduct[].sku", []interface{}{"coins_100", "coins_500", "gems_10"}},
+		{".inappproduct[-1].sku", []interface{}{"gems_10"}},
+		{".inappproduct | length", []interface{}{float64(3)}},
+		{`.inappproduct[] | select(.status == "active") | .sku`, []interface{}{"coins_100", "gems_10"}},
+		{`.inappproduct[] | select(.price >= 2 and .status != "inactive") | .sku`, []interface{}{"gems_10"}},
+		{`.inappproduct[] | select(.price < 2 or (.sku == "coins_500")) | .sku`, []interface{}{"coins_100", "coins_500"}},
+		{".missing.field", []interface{}{nil}},
+		{".tokenPagination | keys", []interface{}{[]interface{}{"nextPageToken"}}},
+	}
+	for _, c := range cases {
+		f, err := Parse(c.expr)
+		if c.want == nil {
+			if err == nil {
+				t.Errorf("Parse(%q) expected error", c.expr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q) error: %v", c.expr, err)
+			continue
+		}
+		got, err := f.Apply(doc)
+		if err != nil {
+			t.Errorf("Apply(%q) error: %v", c.expr, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Apply(%q) = %#v, want %#v", c.expr, got, c.want)
+		}
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	f, err := Parse(".sku[]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Apply(map[string]interface{}{"sku": "x"}); err == nil {
+		t.Error("expected error iterating over a string")
+	}
+	for _, expr := range []string{"", ".a |", "select(.a", `.a == "b`, ".a[x]"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) expected error", expr)
+		}
+	}
+}
@@ -1,6 +1,12 @@
 package main
 
-import "github.com/hassansin/androidpublisher/ui"
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/hassansin/androidpublisher/ui"
+)
 
 type Groups []*Group
 
@@ -12,6 +18,31 @@ func (g Groups) ToNodes() []ui.Node {
 	return nodes
 }
 
+//Find returns the operation with the given full name, e.g. Purchases.subscriptions.Get
+func (g Groups) Find(name string) *Operation {
+	for _, grp := range g {
+		if !strings.HasPrefix(name, grp.Name+".") {
+			continue
+		}
+		for _, op := range grp.Operations {
+			if grp.Name+"."+op.Name == name {
+				return op
+			}
+		}
+	}
+	return nil
+}
+
+//Group returns the group with the given name
+func (g Groups) Group(name string) *Group {
+	for _, grp := range g {
+		if grp.Name == name {
+			return grp
+		}
+	}
+	return nil
+}
+
 type Group struct {
 	Name       string
 	Operations []*Operation
@@ -30,9 +61,15 @@ func (g Group) Children() []ui.Node {
 }
 
 type Operation struct {
-	Name   string
-	Params []*Param
-	Do     func([]*Param) (interface{}, error)
+	Name    string
+	Params  []*Param
+	Timeout time.Duration
+	Do      func(context.Context, []*Param) (interface{}, error)
+	//Preview, if set, lists what Do is about to change, which has to be confirmed before Do runs
+	Preview  func(context.Context, []*Param) (*Preview, error)
+	confirms *Operation
+	//previewed is the operation whose confirmed plan this one runs
+	previewed *Operation
 }
 
 func (op Operation) Title() string {
@@ -43,7 +80,23 @@ func (op Operation) Children() []ui.Node {
 	return nil
 }
 
+//CopyParams returns a copy of the parameters so that the operation can run while the form is filled again
+func (op Operation) CopyParams() []*Param {
+	return copyParams(op.Params)
+}
+
+func copyParams(from []*Param) []*Param {
+	params := make([]*Param, len(from))
+	for i, p := range from {
+		c := *p
+		params[i] = &c
+	}
+	return params
+}
+
 type Param struct {
 	Name, Value         string
 	Required, Multiline bool
+	//Limit is the maximum number of characters, it's counted while typing
+	Limit int
 }
@@ -0,0 +1,223 @@
+//Package images checks store graphics against the Play Store requirements before they're uploaded
+package images
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg" //registers the JPEG decoder
+	_ "image/png"  //registers the PNG decoder
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	androidpublisher "google.golang.org/api/androidpublisher/v3"
+)
+
+const mb = 1024 * 1024
+
+//Spec are the requirements for an image type
+type Spec struct {
+	//Formats are the accepted formats as named by image.DecodeConfig
+	Formats []string
+	//Alpha allows an alpha channel
+	Alpha bool
+	//Width and Height are the exact dimensions, if any
+	Width, Height int
+	//MinSide and MaxSide bound both sides when the dimensions aren't exact
+	MinSide, MaxSide int
+	//Square requires the width and the height to be equal
+	Square bool
+	//MaxCount is the number of images allowed per language
+	MaxCount int
+	MaxBytes int64
+}
+
+var screenshot = Spec{Formats: []string{"jpeg", "png"}, MinSide: 320, MaxSide: 3840, MaxCount: 8, MaxBytes: 8 * mb}
+
+//Specs are the image types and their requirements
+var Specs = map[string]Spec{
+	"icon":                 {Formats: []string{"png"}, Alpha: true, Width: 512, Height: 512, MaxCount: 1, MaxBytes: 1 * mb},
+	"featureGraphic":       {Formats: []string{"jpeg", "png"}, Width: 1024, Height: 500, MaxCount: 1, MaxBytes: 15 * mb},
+	"promoGraphic":         {Formats: []string{"jpeg", "png"}, Width: 180, Height: 120, MaxCount: 1, MaxBytes: 15 * mb},
+	"tvBanner":             {Formats: []string{"jpeg", "png"}, Width: 1280, Height: 720, MaxCount: 1, MaxBytes: 15 * mb},
+	"phoneScreenshots":     screenshot,
+	"sevenInchScreenshots": screenshot,
+	"tenInchScreenshots":   screenshot,
+	"tvScreenshots":        screenshot,
+	"wearScreenshots":      {Formats: []string{"jpeg", "png"}, MinSide: 384, MaxSide: 3840, Square: true, MaxCount: 8, MaxBytes: 8 * mb},
+}
+
+//Types returns the image types in the order they appear in the Play Console
+func Types() []string {
+	return []string{"icon", "featureGraphic", "promoGraphic", "tvBanner", "phoneScreenshots", "sevenInchScreenshots",
+		"tenInchScreenshots", "tvScreenshots", "wearScreenshots"}
+}
+
+//Info describes a local image file
+type Info struct {
+	Path          string
+	Format        string
+	Width, Height int
+	Alpha         bool
+	Size          int64
+}
+
+//Inspect reads the format and the dimensions of an image file
+func Inspect(path string) (Info, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Info{}, err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return Info{}, err
+	}
+	config, format, err := image.DecodeConfig(f)
+	if err != nil {
+		return Info{}, errors.Wrapf(err, "%v is not a PNG or JPEG image", path)
+	}
+	return Info{Path: path, Format: format, Width: config.Width, Height: config.Height, Alpha: hasAlpha(config.ColorModel), Size: stat.Size()}, nil
+}
+
+//hasAlpha reports whether the image has an alpha channel. The PNG decoder uses the NRGBA models for truecolor and
+//grayscale images with alpha, and a palette for indexed ones.
+func hasAlpha(m color.Model) bool {
+	switch m {
+	case color.NRGBAModel, color.NRGBA64Model:
+		return true
+	}
+	if p, ok := m.(color.Palette); ok {
+		for _, c := range p {
+			if _, _, _, a := c.RGBA(); a != 0xffff {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//Check returns an error when info doesn't meet the requirements of spec
+func (spec Spec) Check(info Info) error {
+	var problems []string
+	if !contains(spec.Formats, info.Format) {
+		problems = append(problems, fmt.Sprintf("is %v, expected %v", strings.ToUpper(info.Format), strings.ToUpper(strings.Join(spec.Formats, " or "))))
+	}
+	if info.Alpha && !spec.Alpha {
+		problems = append(problems, "has an alpha channel, expected a 24-bit PNG or a JPEG")
+	}
+	w, h := info.Width, info.Height
+	short, long := w, h
+	if short > long {
+		short, long = long, short
+	}
+	switch {
+	case spec.Width > 0 && (w != spec.Width || h != spec.Height):
+		problems = append(problems, fmt.Sprintf("is %vx%v, expected %vx%v", w, h, spec.Width, spec.Height))
+	case spec.MinSide > 0 && (short < spec.MinSide || long > spec.MaxSide):
+		problems = append(problems, fmt.Sprintf("is %vx%v, sides must be between %v and %v px", w, h, spec.MinSide, spec.MaxSide))
+	case spec.Square && w != h:
+		problems = append(problems, fmt.Sprintf("is %vx%v, expected a square image", w, h))
+	case spec.MinSide > 0 && long > 2*short:
+		problems = append(problems, fmt.Sprintf("is %vx%v, the long side can't be more than twice the short side", w, h))
+	}
+	if spec.MaxBytes > 0 && info.Size > spec.MaxBytes {
+		problems = append(problems, fmt.Sprintf("is %v KB, the limit is %v KB", info.Size/1024, spec.MaxBytes/1024))
+	}
+	if len(problems) > 0 {
+		return errors.Errorf("%v %v", info.Path, strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+//Validate inspects and checks the files to be uploaded for imageType, given the number of images already there
+func Validate(imageType string, paths []string, existing int) ([]Info, error) {
+	spec, ok := Specs[imageType]
+	if !ok {
+		return nil, errors.Errorf("unknown image type %q, expected one of %v", imageType, strings.Join(Types(), ", "))
+	}
+	if existing+len(paths) > spec.MaxCount {
+		return nil, errors.Errorf("%v allows %v images, %v are there already and %v would be added", imageType, spec.MaxCount, existing, len(paths))
+	}
+	var infos []Info
+	var problems []string
+	for _, path := range paths {
+		info, err := Inspect(path)
+		if err == nil {
+			err = spec.Check(info)
+		}
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		infos = append(infos, info)
+	}
+	if len(problems) > 0 {
+		return nil, errors.New(strings.Join(problems, "\n"))
+	}
+	return infos, nil
+}
+
+//Files expands a comma separated list of files and directories to image files. Directories contribute their PNG
+//and JPEG files in name order.
+func Files(list string) ([]string, error) {
+	var files []string
+	for _, path := range strings.Split(list, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		stat, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !stat.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		entries, err := ioutil.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		for _, e := range entries {
+			switch strings.ToLower(filepath.Ext(e.Name())) {
+			case ".png", ".jpg", ".jpeg":
+				if !e.IsDir() {
+					names = append(names, e.Name())
+				}
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			files = append(files, filepath.Join(path, name))
+		}
+	}
+	return files, nil
+}
+
+//Table lists images with their hashes and URLs
+func Table(list []*androidpublisher.Image) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSHA-256\tSHA-1\tURL")
+	for _, img := range list {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", img.Id, img.Sha256, img.Sha1, img.Url)
+	}
+	w.Flush()
+	return b.String()
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package images
+
+import (
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeImage(t *testing.T, dir, name string, img image.Image) string {
+	path := filepath.Join(dir, name)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if strings.HasSuffix(name, ".jpg") {
+		err = jpeg.Encode(f, img, nil)
+	} else {
+		err = png.Encode(f, img)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+//opaque returns an image that's encoded as a 24-bit PNG
+func opaque(w, h int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			img.Set(x, y, color.RGBA{R: 10, G: 20, B: 30, A: 255})
+		}
+	}
+	return img
+}
+
+//transparent returns an image that's encoded as a 32-bit PNG
+func transparent(w, h int) image.Image {
+	return image.NewNRGBA(image.Rect(0, 0, w, h))
+}
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	icon := writeImage(t, dir, "icon.png", transparent(512, 512))
+	smallIcon := writeImage(t, dir, "small.png", transparent(256, 256))
+	screen := writeImage(t, dir, "screen.png", opaque(1080, 1920))
+	screenJPEG := writeImage(t, dir, "screen.jpg", opaque(1080, 1920))
+	alphaScreen := writeImage(t, dir, "alpha.png", transparent(1080, 1920))
+	narrow := writeImage(t, dir, "narrow.png", opaque(400, 1000))
+	tiny := writeImage(t, dir, "tiny.png", opaque(200, 300))
+	text := filepath.Join(dir, "notes.txt")
+	if err := ioutil.WriteFile(text, []byte("not an image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		imageType string
+		paths     []string
+		existing  int
+		err       string
+	}{
+		{"icon", []string{icon}, 0, ""},
+		{"icon", []string{screenJPEG}, 0, "is JPEG, expected PNG"},
+		{"icon", []string{smallIcon}, 0, "is 256x256, expected 512x512"},
+		{"icon", []string{icon}, 1, "allows 1 images"},
+		{"phoneScreenshots", []string{screen, screenJPEG}, 6, ""},
+		{"phoneScreenshots", []string{screen}, 8, "allows 8 images"},
+		{"phoneScreenshots", []string{alphaScreen}, 0, "has an alpha channel"},
+		{"phoneScreenshots", []string{narrow}, 0, "more than twice the short side"},
+		{"phoneScreenshots", []string{tiny}, 0, "sides must be between 320 and 3840 px"},
+		{"phoneScreenshots", []string{text}, 0, "is not a PNG or JPEG image"},
+		{"wearScreenshots", []string{screen}, 0, "expected a square image"},
+		{"banner", []string{screen}, 0, "unknown image type"},
+	}
+	for _, test := range tests {
+		infos, err := Validate(test.imageType, test.paths, test.existing)
+		if test.err == "" {
+			if err != nil {
+				t.Errorf("Validate(%v, %v) failed: %v", test.imageType, test.paths, err)
+			} else if len(infos) != len(test.paths) {
+				t.Errorf("Validate(%v, %v) returned %v infos", test.imageType, test.paths, len(infos))
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("Validate(%v, %v) = %v, expected an error containing %q", test.imageType, test.paths, err, test.err)
+		}
+	}
+}
+
+func TestValidateReportsEveryFile(t *testing.T) {
+	dir := t.TempDir()
+	a := writeImage(t, dir, "a.png", opaque(100, 100))
+	b := writeImage(t, dir, "b.png", opaque(100, 100))
+	_, err := Validate("phoneScreenshots", []string{a, b}, 0)
+	if err == nil || !strings.Contains(err.Error(), a) || !strings.Contains(err.Error(), b) {
+		t.Errorf("expected both files in the error, got %v", err)
+	}
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	shots := filepath.Join(dir, "shots")
+	if err := os.Mkdir(shots, 0755); err != nil {
+		t.Fatal(err)
+	}
+	b := writeImage(t, shots, "2.png", opaque(10, 10))
+	a := writeImage(t, shots, "1.jpg", opaque(10, 10))
+	ioutil.WriteFile(filepath.Join(shots, "README.md"), nil, 0644)
+	icon := writeImage(t, dir, "icon.png", opaque(10, 10))
+
+	files, err := Files(icon + ", " + shots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{icon, a, b}; strings.Join(files, ",") != strings.Join(want, ",") {
+		t.Errorf("Files = %v, expected %v", files, want)
+	}
+	if _, err := Files(filepath.Join(dir, "missing.png")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hassansin/androidpublisher/ui"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+//JobState is the state of a request
+type JobState int
+
+//States of a job
+const (
+	JobRunning JobState = iota
+	JobDone
+	JobFailed
+	JobCancelled
+)
+
+func (s JobState) String() string {
+	switch s {
+	case JobRunning:
+		return "running"
+	case JobDone:
+		return "done"
+	case JobFailed:
+		return "failed"
+	}
+	return "cancelled"
+}
+
+//Job is a request that runs in the background
+type Job struct {
+	ID       int
+	Group    *Group
+	Op       *Operation
+	Params   []*Param
+	Title    string
+	State    JobState
+	Started  time.Time
+	Duration time.Duration
+	Result   interface{}
+	Progress Progress
+	tab      *ui.Tab
+	cancel   context.CancelFunc
+}
+
+func (j Job) String() string {
+	elapsed := j.Duration
+	if j.State == JobRunning {
+		elapsed = time.Since(j.Started)
+	}
+	title := j.Title
+	if j.State == JobRunning && j.Progress.Total > 0 {
+		title = j.Progress.String() + " " + title
+	}
+	return fmt.Sprintf("#%-3v %-9v %6v  %v", j.ID, j.State, elapsed.Round(100*time.Millisecond), title)
+}
+
+//Progress of a job that works through a number of items
+type Progress struct {
+	Done, Failed, Total int
+}
+
+func (p Progress) String() string {
+	s := fmt.Sprintf("%v %v/%v", ui.ProgressBar(p.Done, p.Total, 20), p.Done, p.Total)
+	if p.Failed > 0 {
+		s += fmt.Sprintf(" (%v failed)", p.Failed)
+	}
+	return s
+}
+
+type progressKey struct{}
+
+//reportProgress updates the progress of the job running with ctx
+func reportProgress(ctx context.Context, p Progress) {
+	if fn, ok := ctx.Value(progressKey{}).(func(Progress)); ok {
+		fn(p)
+	}
+}
+
+//Jobs runs operations concurrently, each with its own parameters, context and result
+type Jobs struct {
+	mu       sync.Mutex
+	jobs     []*Job
+	onChange func(Job)
+}
+
+//OnChange binds function to be called when a job starts, reports progress or finishes
+func (js *Jobs) OnChange(fn func(Job)) {
+	js.onChange = fn
+}
+
+//Start runs op in the background with a copy of its current parameters.
+//The result is meant to be loaded into tab.
+func (js *Jobs) Start(grp *Group, op *Operation, tab *ui.Tab) Job {
+	return js.StartWith(grp, op, op.Params, tab)
+}
+
+//StartWith runs op in the background with a copy of params
+func (js *Jobs) StartWith(grp *Group, op *Operation, params []*Param, tab *ui.Tab) Job {
+	timeout := op.Timeout
+	if timeout == 0 {
+		timeout = viper.GetDuration("timeout")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	params = copyParams(params)
+
+	js.mu.Lock()
+	job := &Job{
+		ID:      len(js.jobs) + 1,
+		Group:   grp,
+		Op:      op,
+		Params:  params,
+		Title:   requestTitle(grp, op, params),
+		State:   JobRunning,
+		Started: time.Now(),
+		tab:     tab,
+		cancel:  cancel,
+	}
+	js.jobs = append(js.jobs, job)
+	started := *job
+	js.mu.Unlock()
+	js.notify(started)
+
+	ctx = context.WithValue(ctx, progressKey{}, func(p Progress) {
+		js.mu.Lock()
+		job.Progress = p
+		current := *job
+		js.mu.Unlock()
+		js.notify(current)
+	})
+
+	go func() {
+		defer cancel()
+		result, err := op.Do(ctx, params)
+
+		js.mu.Lock()
+		job.Duration = time.Since(job.Started)
+		switch {
+		case err == nil:
+			job.State = JobDone
+		case ctx.Err() == context.Canceled:
+			job.State = JobCancelled
+			result = errors.New("request cancelled")
+		case ctx.Err() == context.DeadlineExceeded:
+			job.State = JobFailed
+			result = errors.Errorf("request timed out after %v", timeout)
+		default:
+			job.State = JobFailed
+			result = err
+		}
+		job.Result = result
+		finished := *job
+		js.mu.Unlock()
+		js.notify(finished)
+	}()
+	return started
+}
+
+func (js *Jobs) notify(job Job) {
+	if js.onChange != nil {
+		js.onChange(job)
+	}
+}
+
+//Cancel cancels the job with the given id if it's still running
+func (js *Jobs) Cancel(id int) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	for _, job := range js.jobs {
+		if job.ID == id && job.State == JobRunning {
+			job.cancel()
+		}
+	}
+}
+
+//CancelLast cancels the most recently started job that is still running
+func (js *Jobs) CancelLast() {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	for i := len(js.jobs) - 1; i >= 0; i-- {
+		if js.jobs[i].State == JobRunning {
+			js.jobs[i].cancel()
+			return
+		}
+	}
+}
+
+//List returns a snapshot of all jobs, newest first
+func (js *Jobs) List() []Job {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	list := make([]Job, len(js.jobs))
+	for i, job := range js.jobs {
+		list[len(js.jobs)-1-i] = *job
+	}
+	return list
+}
+
+//Last returns the most recently started job
+func (js *Jobs) Last() (Job, bool) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	if len(js.jobs) == 0 {
+		return Job{}, false
+	}
+	return *js.jobs[len(js.jobs)-1], true
+}
+
+//Summary counts the jobs by state, e.g. "2 running, 1 failed"
+func (js *Jobs) Summary() string {
+	counts := map[JobState]int{}
+	for _, job := range js.List() {
+		counts[job.State]++
+	}
+	var parts []string
+	for _, s := range []JobState{JobRunning, JobDone, JobFailed, JobCancelled} {
+		if counts[s] > 0 {
+			parts = append(parts, fmt.Sprintf("%v %v", counts[s], s))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
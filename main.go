@@ -1,13 +1,21 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"log"
+	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/hassansin/androidpublisher/apierror"
+	"github.com/hassansin/androidpublisher/diff"
+	"github.com/hassansin/androidpublisher/reply"
+	"github.com/hassansin/androidpublisher/transport"
 	"github.com/hassansin/androidpublisher/ui"
 
 	"github.com/hassansin/gocui"
@@ -26,8 +34,12 @@ var (
 	sideView      *ui.TreeView
 	groups        Groups
 	defaultStatus = fmt.Sprintf("%v:Switch Panel %v:Request %v:Save Response %v:Quit %v:Navigate %v:Refresh", aurora.Cyan("TAB"), aurora.Cyan("ENTER"), aurora.Cyan("CTRL+S"), aurora.Cyan("CTRL+C"), aurora.Cyan("↑↓"), aurora.Cyan("F5"))
-	activeGr      *Group
-	activeOp      *Operation
+	jobs          = &Jobs{}
+	jobsList      *ui.List
+	shownJobs     []Job
+	recorder      *transport.Recorder
+	quota         *transport.Quota
+	trafficList   *ui.List
 )
 
 func nextView(g *gocui.Gui, v *gocui.View) error {
@@ -39,16 +51,33 @@ func nextView(g *gocui.Gui, v *gocui.View) error {
 }
 
 func processOp(g *gocui.Gui, v *gocui.View) error {
+	return requestOp(g, mainView.CurrentTab())
+}
+
+func processOpInNewTab(g *gocui.Gui, v *gocui.View) error {
+	return requestOp(g, nil)
+}
+
+//requestOp asks for the parameters of the selected operation and loads the response into tab,
+//or a new tab if tab is nil
+func requestOp(g *gocui.Gui, tab *ui.Tab) error {
 	status.Reset()
 	idx := sideView.Selected()
 	if len(idx) < 2 {
 		return nil
 	}
 	grp := groups[idx[0]]
-	op := grp.Operations[idx[1]]
+	return showParams(g, grp, grp.Operations[idx[1]], tab)
+}
+
+//showParams asks for the parameters of op, starting with their current values, and runs it
+func showParams(g *gocui.Gui, grp *Group, op *Operation, tab *ui.Tab) error {
 	maxX, maxY := g.Size()
 	if len(op.Params) == 0 {
-		go makeRequest(g, grp, op)
+		if tab == nil {
+			tab = mainView.NewTab()
+		}
+		startOp(grp, op, tab)
 		return nil
 	}
 	f, err := ui.NewForm(g, "Parameters", maxX/2-30, maxY/2-int(float64(len(op.Params))*4/2)-1)
@@ -60,7 +89,10 @@ func processOp(g *gocui.Gui, v *gocui.View) error {
 	}).OnError(func(err error) {
 		status.UpdateError(err.Error())
 	}).OnSubmit(func() error {
-		go makeRequest(g, grp, op)
+		if tab == nil {
+			tab = mainView.NewTab()
+		}
+		startOp(grp, op, tab)
 		return sideView.SetCurrent()
 	})
 	for i, param := range op.Params {
@@ -73,6 +105,13 @@ func processOp(g *gocui.Gui, v *gocui.View) error {
 			input.Rows = 6
 		}
 		input.Required = param.Required
+		if param.Limit > 0 {
+			name, limit := param.Name, param.Limit
+			input.OnChange = func(value string) {
+				countChars(name, value, limit)
+			}
+			countChars(name, param.Value, limit)
+		}
 		if err := f.Input(input); err != nil {
 			return err
 		}
@@ -80,28 +119,127 @@ func processOp(g *gocui.Gui, v *gocui.View) error {
 	return nil
 }
 
+//countChars shows the number of characters of a parameter value against its limit
+func countChars(name, value string, limit int) {
+	msg := fmt.Sprintf("%v: %v/%v characters", name, reply.Length(value), limit)
+	if reply.Length(value) > limit {
+		status.UpdateError(msg)
+		return
+	}
+	status.Update(msg)
+}
+
+//reRequest repeats the last job with the parameters it ran with. A job that ran a confirmed plan is repeated from
+//its preview, so that the changes are confirmed again.
 func reRequest(g *gocui.Gui, v *gocui.View) error {
-	if activeGr != nil && activeOp != nil {
-		go makeRequest(g, activeGr, activeOp)
+	job, ok := jobs.Last()
+	if !ok {
+		return nil
+	}
+	op := job.Op
+	if op.previewed != nil {
+		op = previewOperation(op.previewed)
 	}
+	jobs.StartWith(job.Group, op, job.Params, mainView.CurrentTab())
 	return nil
 }
 
-func makeRequest(g *gocui.Gui, grp *Group, op *Operation) {
-	status.Update("Loading...")
-	activeGr = grp
-	activeOp = op
-	result, err := op.Do(op.Params)
+//jobChanged updates the status line, the response and the jobs panel when a job starts or finishes
+func jobChanged(g *gocui.Gui, job Job) {
 	g.Update(func(g *gocui.Gui) error {
-		if err != nil {
-			status.UpdateError("Request failed")
-			result = err
-		} else {
-			status.UpdateSuccess("Request successful")
+		summary := jobs.Summary()
+		switch job.State {
+		case JobRunning:
+			loading := "Loading..."
+			if job.Progress.Total > 0 {
+				loading = job.Progress.String()
+			}
+			status.Update(fmt.Sprintf("#%v %v (%v) %v:Cancel %v:Jobs", job.ID, loading, summary, aurora.Cyan("CTRL+G"), aurora.Cyan("F2")))
+		case JobDone:
+			status.UpdateSuccess(fmt.Sprintf("#%v Request successful (%v)", job.ID, summary))
+		case JobCancelled:
+			status.UpdateError(fmt.Sprintf("#%v Request cancelled (%v)", job.ID, summary))
+		default:
+			status.UpdateError(fmt.Sprintf("#%v Request failed: %v (%v)", job.ID, apierror.Summary(job.Result.(error)), summary))
+		}
+		if job.State != JobRunning {
+			mainView.Load(job.tab, job.Title, job.Result)
+		}
+		if job.State == JobDone {
+			if err := recordJob(job); err != nil {
+				status.UpdateError(err.Error())
+			}
+		}
+		if jobsList != nil {
+			jobsList.SetItems(jobItems())
+		}
+		if job.State == JobDone && job.Op.confirms != nil {
+			return confirm(g, job)
+		}
+		return nil
+	})
+}
+
+func cancelOp(g *gocui.Gui, v *gocui.View) error {
+	jobs.CancelLast()
+	return nil
+}
+
+//jobItems takes a snapshot of the jobs for the jobs panel and returns its items
+func jobItems() []string {
+	shownJobs = jobs.List()
+	items := make([]string, len(shownJobs))
+	for i, job := range shownJobs {
+		items[i] = job.String()
+	}
+	return items
+}
+
+//showJobs opens the jobs panel, picking a job shows its result
+func showJobs(g *gocui.Gui, v *gocui.View) error {
+	if jobsList != nil {
+		return nil
+	}
+	if len(jobs.List()) == 0 {
+		return nil
+	}
+	current := g.CurrentView()
+	restore := func() error {
+		jobsList = nil
+		if current == nil {
+			return sideView.SetCurrent()
 		}
-		mainView.LoadContent(op.Name+" "+grp.Name, result)
+		_, err := g.SetCurrentView(current.Name())
+		return err
+	}
+	jobsList = ui.NewList(g, "Jobs", jobItems()).OnSelect(func(idx int) error {
+		job := shownJobs[idx]
+		if job.State != JobRunning && !mainView.ShowTab(job.tab) {
+			mainView.Load(mainView.NewTab(), job.Title, job.Result)
+		}
+		jobsList = nil
+		return mainView.SetCurrent()
+	}).OnCancel(restore).Bind('c', func(idx int) error {
+		jobs.Cancel(shownJobs[idx].ID)
 		return nil
 	})
+	status.Update(fmt.Sprintf("%v:Show Result %v:Cancel Job %v:Close", aurora.Cyan("ENTER"), aurora.Cyan("c"), aurora.Cyan("ESC")))
+	return jobsList.Show()
+}
+
+//requestTitle describes the operation and the parameters it was called with
+func requestTitle(grp *Group, op *Operation, values []*Param) string {
+	title := op.Name + " " + grp.Name
+	var params []string
+	for _, p := range values {
+		if p.Value != "" && !p.Multiline {
+			params = append(params, fmt.Sprintf("%v=%v", p.Name, p.Value))
+		}
+	}
+	if len(params) > 0 {
+		title += " " + strings.Join(params, " ")
+	}
+	return title
 }
 
 func quit(g *gocui.Gui, v *gocui.View) error {
@@ -124,9 +262,18 @@ func keybindings(g *gocui.Gui) error {
 	if err := g.SetKeybinding(sideView.Name(), gocui.KeyEnter, gocui.ModNone, processOp); err != nil {
 		return err
 	}
+	if err := g.SetKeybinding(sideView.Name(), 't', gocui.ModNone, processOpInNewTab); err != nil {
+		return err
+	}
 	if err := g.SetKeybinding(sideView.Name(), gocui.KeyF5, gocui.ModNone, reRequest); err != nil {
 		return err
 	}
+	if err := g.SetKeybinding(mainView.Name(), 'r', gocui.ModNone, replyToReview); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding(mainView.Name(), 'a', gocui.ModNone, orderActions); err != nil {
+		return err
+	}
 	if err := g.SetKeybinding("", gocui.KeyArrowDown, gocui.ModNone, func(_ *gocui.Gui, _ *gocui.View) error {
 		status.Reset()
 		return nil
@@ -139,6 +286,21 @@ func keybindings(g *gocui.Gui) error {
 	}); err != nil {
 		return err
 	}
+	if err := g.SetKeybinding("", gocui.KeyCtrlG, gocui.ModNone, cancelOp); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("", gocui.KeyF2, gocui.ModNone, showJobs); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("", gocui.KeyF3, gocui.ModNone, showTraffic); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("", gocui.KeyF4, gocui.ModNone, showQuota); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("", gocui.KeyF6, gocui.ModNone, showNotifications); err != nil {
+		return err
+	}
 	if err := g.SetKeybinding("", gocui.KeyCtrlC, gocui.ModNone, quit); err != nil {
 		return err
 	}
@@ -148,6 +310,19 @@ func keybindings(g *gocui.Gui) error {
 func init() {
 	pflag.String("package", "", "android package name")
 	pflag.String("credentials", "credentials.json", "path to google service account JSON credentials file")
+	pflag.Duration("timeout", time.Minute, "default timeout of a request")
+	pflag.Int("retries", transport.DefaultRetryPolicy.MaxRetries, "number of retries for quota and transient server errors")
+	pflag.Duration("retry-max-wait", transport.DefaultRetryPolicy.MaxDelay, "maximum backoff between retries")
+	pflag.Bool("retry-non-idempotent", false, "also retry POST and PATCH requests")
+	pflag.Float64("rate-limit", 0, "maximum number of requests per second, 0 for no limit")
+	pflag.Int("burst", 5, "number of requests that may exceed --rate-limit at once")
+	pflag.Int("daily-budget", 0, "number of API calls per day to warn about, 0 for no budget")
+	pflag.Float64("budget-warning", 0.8, "fraction of --daily-budget after which a warning is shown")
+	pflag.String("data-dir", "", "directory for local data (default ~/.androidpublisher)")
+	pflag.String("rtdn-listen", "", "address to receive real-time developer notifications from Pub/Sub push subscriptions on, e.g. localhost:8080")
+	pflag.String("rtdn-token", "", "secret that push requests have to carry in their token query parameter")
+	pflag.String("reply-templates", "", "JSON file with review reply templates (default reply-templates.json in --data-dir)")
+	pflag.StringToString("operation-timeout", nil, "timeout of individual operations, e.g. Inappproducts.List=2m,Reviews.Get=10s")
 
 	pflag.Parse()
 	viper.BindPFlags(pflag.CommandLine)
@@ -165,6 +340,33 @@ func createLayout(g *gocui.Gui) func(*gocui.Gui) error {
 		}
 		status.UpdateSuccess(fmt.Sprintf("File saved to %v", filename))
 	})
+	mainView.OnFilter(func(expr string, err error) {
+		switch {
+		case err != nil:
+			status.UpdateError(err.Error())
+		case expr == "":
+			status.UpdateSuccess("Filter removed")
+		default:
+			status.UpdateSuccess(fmt.Sprintf("Filter applied: %v", expr))
+		}
+	})
+	mainView.OnCompare(func(pinned string, changes []diff.Change) {
+		if pinned == "" {
+			status.Reset()
+			return
+		}
+		status.Update(fmt.Sprintf("Compared with %v: %v  %v:Unpin", pinned, diff.Summary(changes), aurora.Cyan("u")))
+	})
+	mainView.OnSearch(func(query string, current, total int) {
+		switch {
+		case query == "":
+			status.Reset()
+		case total == 0:
+			status.UpdateError(fmt.Sprintf("No matches for %q", query))
+		default:
+			status.Update(fmt.Sprintf("%q: match %v of %v  %v:Next %v:Previous", query, current, total, aurora.Cyan("n"), aurora.Cyan("N")))
+		}
+	})
 	return func(g *gocui.Gui) error {
 		if err := sideView.SetView("Operations", groups.ToNodes()); err != nil {
 			return err
@@ -186,77 +388,98 @@ func initOperations(service *androidpublisher.Service, pkgName string) {
 	grp.Operations = append(grp.Operations, &Operation{
 		Name:   "List",
 		Params: []*Param{{Name: "PageToken"}},
-		Do: func(params []*Param) (interface{}, error) {
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
 			s := androidpublisher.NewInappproductsService(service)
 			call := s.List(pkgName)
 			if params[0].Value != "" {
 				call.Token(params[0].Value)
 			}
-			return call.Do()
+			return call.Context(ctx).Do()
 		},
 	})
 	grp.Operations = append(grp.Operations, &Operation{
 		Name:   "Delete",
 		Params: []*Param{{Name: "SKU", Required: true}},
-		Do: func(params []*Param) (interface{}, error) {
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
 			s := androidpublisher.NewInappproductsService(service)
 			call := s.Delete(pkgName, params[0].Value)
-			return nil, call.Do()
+			return nil, call.Context(ctx).Do()
 		},
 	})
 	grp.Operations = append(grp.Operations, &Operation{
 		Name:   "Get",
 		Params: []*Param{{Name: "SKU", Required: true}},
-		Do: func(params []*Param) (interface{}, error) {
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
 			s := androidpublisher.NewInappproductsService(service)
 			call := s.Get(pkgName, params[0].Value)
-			return call.Do()
+			return call.Context(ctx).Do()
 		},
 	})
 	grp.Operations = append(grp.Operations, &Operation{
 		Name:   "Patch",
 		Params: []*Param{{Name: "SKU", Required: true}, {Name: "Body", Multiline: true}},
-		Do: func(params []*Param) (interface{}, error) {
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
 			s := androidpublisher.NewInappproductsService(service)
 			call := s.Get(pkgName, params[0].Value)
-			return call.Do()
+			return call.Context(ctx).Do()
 		},
 	})
+	grp.Operations = append(grp.Operations, exportCatalogOperation(service, pkgName))
+	grp.Operations = append(grp.Operations, importCatalogOperation(service, pkgName))
+
+	initEditOperations(service, pkgName)
+
+	grp = &Group{Name: "Metadata"}
+	groups = append(groups, grp)
+	grp.Operations = append(grp.Operations, syncMetadataOperation(service, pkgName))
+	grp.Operations = append(grp.Operations, downloadMetadataOperation(service, pkgName))
+
+	grp = &Group{Name: "Internalappsharingartifacts"}
+	groups = append(groups, grp)
+	grp.Operations = append(grp.Operations, shareOperation(service, pkgName, "UploadApk", "apk"))
+	grp.Operations = append(grp.Operations, shareOperation(service, pkgName, "UploadBundle", "bundle"))
+	grp.Operations = append(grp.Operations, recentArtifactsOperation(pkgName))
+
 	grp = &Group{Name: "Orders"}
 	groups = append(groups, grp)
 	grp.Operations = append(grp.Operations, &Operation{
 		Name:   "Refund",
 		Params: []*Param{{Name: "OrderID", Required: true}, {Name: "Revoke (true/false)"}},
-		Do: func(params []*Param) (interface{}, error) {
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
 			s := androidpublisher.NewOrdersService(service)
 			call := s.Refund(pkgName, params[0].Value)
 			if strings.ToLower(params[1].Value) == "true" {
 				call.Revoke(true)
 			}
-			return nil, call.Do()
+			return nil, call.Context(ctx).Do()
 		},
 	})
+	grp.Operations = append(grp.Operations, refundOrdersOperation(service, pkgName))
+	grp.Operations = append(grp.Operations, lookupOrderOperation(service, pkgName))
 
 	grp = &Group{Name: "Purchases.products"}
 	groups = append(groups, grp)
 	grp.Operations = append(grp.Operations, &Operation{
 		Name:   "Get",
 		Params: []*Param{{Name: "ProductID", Required: true}, {Name: "Token", Required: true}},
-		Do: func(params []*Param) (interface{}, error) {
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
 			s := androidpublisher.NewPurchasesProductsService(service)
 			call := s.Get(pkgName, params[0].Value, params[1].Value)
-			return call.Do()
+			return call.Context(ctx).Do()
 		},
 	})
+	grp.Operations = append(grp.Operations, acknowledgeOperation(productAcknowledger(service, pkgName), "ProductID"))
+	grp.Operations = append(grp.Operations, verifyProductsOperation(service, pkgName))
+	grp.Operations = append(grp.Operations, bulkAcknowledgeOperation(productAcknowledger(service, pkgName)))
 	grp = &Group{Name: "Purchases.subscriptions"}
 	groups = append(groups, grp)
 	grp.Operations = append(grp.Operations, &Operation{
 		Name:   "Cancel",
 		Params: []*Param{{Name: "SubscriptionId", Required: true}, {Name: "Token", Required: true}},
-		Do: func(params []*Param) (interface{}, error) {
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
 			s := androidpublisher.NewPurchasesSubscriptionsService(service)
 			call := s.Cancel(pkgName, params[0].Value, params[1].Value)
-			return nil, call.Do()
+			return nil, call.Context(ctx).Do()
 		},
 	})
 	grp.Operations = append(grp.Operations, &Operation{
@@ -265,7 +488,7 @@ func initOperations(service *androidpublisher.Service, pkgName string) {
 			{Name: "SubscriptionId", Required: true}, {Name: "Token", Required: true},
 			{Name: "DesiredExpiryTimeMillis", Required: true}, {Name: "ExpectedExpiryTimeMillis", Required: true},
 		},
-		Do: func(params []*Param) (interface{}, error) {
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
 			s := androidpublisher.NewPurchasesSubscriptionsService(service)
 			desired, err := strconv.ParseInt(params[2].Value, 10, 64)
 			if err != nil {
@@ -281,43 +504,48 @@ func initOperations(service *androidpublisher.Service, pkgName string) {
 					ExpectedExpiryTimeMillis: expected,
 				},
 			})
-			return call.Do()
+			return call.Context(ctx).Do()
 		},
 	})
 	grp.Operations = append(grp.Operations, &Operation{
 		Name:   "Get",
 		Params: []*Param{{Name: "SubscriptionId", Required: true}, {Name: "Token", Required: true}},
-		Do: func(params []*Param) (interface{}, error) {
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
 			s := androidpublisher.NewPurchasesSubscriptionsService(service)
 			call := s.Get(pkgName, params[0].Value, params[1].Value)
-			return call.Do()
+			return call.Context(ctx).Do()
 		},
 	})
 	grp.Operations = append(grp.Operations, &Operation{
 		Name:   "Refund",
 		Params: []*Param{{Name: "SubscriptionId", Required: true}, {Name: "Token", Required: true}},
-		Do: func(params []*Param) (interface{}, error) {
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
 			s := androidpublisher.NewPurchasesSubscriptionsService(service)
 			call := s.Refund(pkgName, params[0].Value, params[1].Value)
-			return nil, call.Do()
+			return nil, call.Context(ctx).Do()
 		},
 	})
 	grp.Operations = append(grp.Operations, &Operation{
 		Name:   "Revoke",
 		Params: []*Param{{Name: "SubscriptionId", Required: true}, {Name: "Token", Required: true}},
-		Do: func(params []*Param) (interface{}, error) {
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
 			s := androidpublisher.NewPurchasesSubscriptionsService(service)
 			call := s.Revoke(pkgName, params[0].Value, params[1].Value)
-			return nil, call.Do()
+			return nil, call.Context(ctx).Do()
 		},
 	})
+	grp.Operations = append(grp.Operations, acknowledgeOperation(subscriptionAcknowledger(service, pkgName), "SubscriptionId"))
+	grp.Operations = append(grp.Operations, verifySubscriptionsOperation(service, pkgName))
+	grp.Operations = append(grp.Operations, bulkAcknowledgeOperation(subscriptionAcknowledger(service, pkgName)))
+	grp.Operations = append(grp.Operations, refundSubscriptionsOperation(service, pkgName))
+	grp.Operations = append(grp.Operations, explainSubscriptionOperation(service, pkgName))
 
 	grp = &Group{Name: "Purchases.voidedpurchases"}
 	groups = append(groups, grp)
 	grp.Operations = append(grp.Operations, &Operation{
 		Name:   "List",
 		Params: []*Param{{Name: "StartTime(milliseconds)"}, {Name: "EndTime(milliseconds)"}, {Name: "MaxResults"}, {Name: "PageToken"}},
-		Do: func(params []*Param) (interface{}, error) {
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
 			s := androidpublisher.NewPurchasesVoidedpurchasesService(service)
 			call := s.List(pkgName)
 			if params[0].Value != "" {
@@ -335,16 +563,18 @@ func initOperations(service *androidpublisher.Service, pkgName string) {
 			if params[3].Value != "" {
 				call.Token(params[3].Value)
 			}
-			return call.Do()
+			return call.Context(ctx).Do()
 		},
 	})
+	grp.Operations = append(grp.Operations, syncVoidedOperation(service, pkgName))
+	grp.Operations = append(grp.Operations, voidedReportOperation(pkgName))
 
 	grp = &Group{Name: "Reviews"}
 	groups = append(groups, grp)
 	grp.Operations = append(grp.Operations, &Operation{
 		Name:   "List",
 		Params: []*Param{{Name: "MaxResults"}, {Name: "PageToken"}},
-		Do: func(params []*Param) (interface{}, error) {
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
 			s := androidpublisher.NewReviewsService(service)
 			call := s.List(pkgName)
 			if params[0].Value != "" {
@@ -354,25 +584,74 @@ func initOperations(service *androidpublisher.Service, pkgName string) {
 			if params[1].Value != "" {
 				call.Token(params[1].Value)
 			}
-			return call.Do()
+			return call.Context(ctx).Do()
 		},
 	})
 	grp.Operations = append(grp.Operations, &Operation{
 		Name:   "Get",
 		Params: []*Param{{Name: "ReviewID", Required: true}},
-		Do: func(params []*Param) (interface{}, error) {
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
 			s := androidpublisher.NewReviewsService(service)
 			call := s.Get(pkgName, params[0].Value)
-			return call.Do()
+			return call.Context(ctx).Do()
+		},
+	})
+	grp.Operations = append(grp.Operations, dashboardOperation(service, pkgName))
+	grp.Operations = append(grp.Operations, syncOperation(service, pkgName))
+	grp.Operations = append(grp.Operations, archiveOperation(pkgName))
+	grp.Operations = append(grp.Operations, &Operation{
+		Name:   "Reply",
+		Params: []*Param{{Name: "ReviewID", Required: true}, {Name: "Reply", Required: true, Multiline: true, Limit: reply.MaxLength}},
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
+			if n := reply.Length(params[1].Value); n > reply.MaxLength {
+				return nil, errors.Errorf("reply is %v characters long, the limit is %v", n, reply.MaxLength)
+			}
+			s := androidpublisher.NewReviewsService(service)
+			call := s.Reply(pkgName, params[0].Value, &androidpublisher.ReviewsReplyRequest{ReplyText: params[1].Value})
+			return call.Context(ctx).Do()
 		},
 	})
 }
 
+//newRetryTransport wraps base with the retry policy set by the flags
+func newRetryTransport(base http.RoundTripper) http.RoundTripper {
+	policy := transport.DefaultRetryPolicy
+	policy.MaxRetries = viper.GetInt("retries")
+	policy.MaxDelay = viper.GetDuration("retry-max-wait")
+	policy.NonIdempotent = viper.GetBool("retry-non-idempotent")
+	rt := transport.NewRetry(base, policy)
+	rt.OnRetry = func(req *http.Request, attempt int, wait time.Duration, reason string) {
+		status.UpdateError(fmt.Sprintf("%v %v: %v, retry %v/%v in %v", req.Method, req.URL.Path, reason, attempt, policy.MaxRetries, wait.Round(time.Millisecond)))
+	}
+	return rt
+}
+
+//setTimeouts applies the --operation-timeout flag to the operations
+func setTimeouts() error {
+	timeouts, err := pflag.CommandLine.GetStringToString("operation-timeout")
+	if err != nil {
+		return err
+	}
+	for name, value := range timeouts {
+		op := groups.Find(name)
+		if op == nil {
+			return errors.Errorf("unknown operation %v in --operation-timeout", name)
+		}
+		if op.Timeout, err = time.ParseDuration(value); err != nil {
+			return errors.Wrapf(err, "invalid timeout for %v", name)
+		}
+	}
+	return nil
+}
+
 func do() error {
 	pkgName := viper.GetString("package")
 	if pkgName == "" {
 		return errors.New("missing android package name")
 	}
+	if viper.GetString("rtdn-listen") != "" && viper.GetString("rtdn-token") == "" {
+		return errors.New("--rtdn-listen requires --rtdn-token, the secret that push requests have to carry")
+	}
 
 	data, err := ioutil.ReadFile(viper.GetString("credentials"))
 	if err != nil {
@@ -383,12 +662,19 @@ func do() error {
 	if err != nil {
 		return err
 	}
-	client := conf.Client(oauth2.NoContext)
+	base := transport.NewRateLimit(http.DefaultTransport, viper.GetFloat64("rate-limit"), viper.GetInt("burst"))
+	quota = transport.NewQuota(base, filepath.Join(dataDir(), "quota.json"))
+	recorder = transport.NewRecorder(quota)
+	client := conf.Client(context.WithValue(oauth2.NoContext, oauth2.HTTPClient, &http.Client{Transport: recorder}))
+	client.Transport = newRetryTransport(client.Transport)
 	service, err := androidpublisher.New(client)
 	if err != nil {
 		return err
 	}
 	initOperations(service, pkgName)
+	if err := setTimeouts(); err != nil {
+		return err
+	}
 
 	g, err := gocui.NewGui(gocui.Output256)
 	if err != nil {
@@ -402,10 +688,28 @@ func do() error {
 	g.SelFgColor = gocui.ColorWhite | gocui.AttrBold
 
 	g.SetManagerFunc(createLayout(g))
+	jobs.OnChange(func(job Job) {
+		jobChanged(g, job)
+	})
+	quota.OnCall = func(usage transport.Usage) {
+		quotaChanged(usage)
+	}
+	quotaChanged(quota.Usage())
+	recorder.OnRecord = func(transport.Exchange) {
+		g.Update(func(g *gocui.Gui) error {
+			if trafficList != nil {
+				trafficList.SetItems(exchangeItems())
+			}
+			return nil
+		})
+	}
 
 	if err := keybindings(g); err != nil {
 		return err
 	}
+	if addr := viper.GetString("rtdn-listen"); addr != "" {
+		receiveNotifications(g, addr, viper.GetString("rtdn-token"))
+	}
 
 	if err := g.MainLoop(); err != nil && err != gocui.ErrQuit {
 		return err
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/hassansin/androidpublisher/images"
+	"github.com/hassansin/androidpublisher/metadata"
+	"github.com/pkg/errors"
+	androidpublisher "google.golang.org/api/androidpublisher/v3"
+)
+
+//defaultTrack is the track whose release notes are synced when none is given
+const defaultTrack = "production"
+
+//MetadataResult summarizes a sync or download of the metadata directory
+type MetadataResult struct {
+	Dir          string `json:"dir"`
+	Edit         string `json:"edit,omitempty"`
+	Locales      int    `json:"locales,omitempty"`
+	Listings     int    `json:"listings,omitempty"`
+	Images       int    `json:"images,omitempty"`
+	ReleaseNotes int    `json:"releaseNotes,omitempty"`
+}
+
+func trackParam(param *Param) string {
+	if param.Value == "" {
+		return defaultTrack
+	}
+	return param.Value
+}
+
+//loadStore reads the listings and the track of an edit, and lists the images of the given types per language
+func loadStore(ctx context.Context, service *androidpublisher.Service, pkgName, edit, track string, types map[string][]string) (*metadata.Store, error) {
+	store := &metadata.Store{Listings: map[string]*androidpublisher.Listing{}, Images: map[string]map[string][]string{}}
+	listings, err := androidpublisher.NewEditsListingsService(service).List(pkgName, edit).Context(ctx).Do()
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range listings.Listings {
+		store.Listings[l.Language] = l
+	}
+	if store.Track, err = androidpublisher.NewEditsTracksService(service).Get(pkgName, edit, track).Context(ctx).Do(); err != nil {
+		return nil, errors.Wrapf(err, "track %v", track)
+	}
+	s := androidpublisher.NewEditsImagesService(service)
+	for language, list := range types {
+		store.Images[language] = map[string][]string{}
+		if store.Listings[language] == nil {
+			//a language without a listing has no images either
+			continue
+		}
+		for _, t := range list {
+			res, err := s.List(pkgName, edit, language, t).Context(ctx).Do()
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v %v", language, t)
+			}
+			for _, img := range res.Images {
+				store.Images[language][t] = append(store.Images[language][t], img.Sha256)
+			}
+		}
+	}
+	return store, nil
+}
+
+//planMetadata compares the metadata directory with an edit. Local images are validated first, so an invalid file
+//fails the plan instead of the edit.
+func planMetadata(ctx context.Context, service *androidpublisher.Service, pkgName, edit string, params []*Param) (*metadata.Plan, error) {
+	locales, err := metadata.Read(params[0].Value)
+	if err != nil {
+		return nil, err
+	}
+	types := map[string][]string{}
+	for _, l := range locales {
+		for _, t := range images.Types() {
+			if files := l.Images[t]; len(files) > 0 {
+				if _, err := images.Validate(t, files, 0); err != nil {
+					return nil, errors.Wrap(err, l.Language)
+				}
+				types[l.Language] = append(types[l.Language], t)
+			}
+		}
+	}
+	store, err := loadStore(ctx, service, pkgName, edit, trackParam(params[1]), types)
+	if err != nil {
+		return nil, err
+	}
+	return metadata.Compare(locales, store)
+}
+
+//syncMetadataOperation makes the store listings, images and release notes match the metadata directory in one
+//edit, once the planned changes are confirmed
+func syncMetadataOperation(service *androidpublisher.Service, pkgName string) *Operation {
+	edits := androidpublisher.NewEditsService(service)
+	return &Operation{
+		Name:    "Sync",
+		Params:  []*Param{{Name: "Dir(e.g. metadata/android)", Required: true}, {Name: "Track(default: production)"}},
+		Timeout: bulkTimeout,
+		Preview: func(ctx context.Context, params []*Param) (*Preview, error) {
+			edit, err := edits.Insert(pkgName, &androidpublisher.AppEdit{}).Context(ctx).Do()
+			if err != nil {
+				return nil, err
+			}
+			defer edits.Delete(pkgName, edit.Id).Context(context.Background()).Do()
+			p, err := planMetadata(ctx, service, pkgName, edit.Id, params)
+			if err != nil {
+				return nil, err
+			}
+			return &Preview{Action: "change", Count: p.Len(), Items: p.Summary(), Plan: p}, nil
+		},
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
+			confirmed, ok := confirmedPlan(ctx).(*metadata.Plan)
+			if !ok {
+				return nil, errNotConfirmed
+			}
+			edit, err := edits.Insert(pkgName, &androidpublisher.AppEdit{}).Context(ctx).Do()
+			if err != nil {
+				return nil, err
+			}
+			result, err := applyMetadata(ctx, service, pkgName, edit.Id, params, confirmed)
+			if err != nil {
+				edits.Delete(pkgName, edit.Id).Context(context.Background()).Do()
+				return nil, err
+			}
+			if _, err := edits.Commit(pkgName, edit.Id).Context(ctx).Do(); err != nil {
+				//an edit left open blocks the next one, the sync can simply be run again
+				if derr := edits.Delete(pkgName, edit.Id).Context(context.Background()).Do(); derr != nil {
+					return nil, errors.Wrapf(err, "committing edit %v, the edit is still open and couldn't be deleted (%v)", edit.Id, derr)
+				}
+				return nil, errors.Wrapf(err, "committing edit %v, the edit was deleted and nothing was changed", edit.Id)
+			}
+			return result, nil
+		},
+	}
+}
+
+//applyMetadata makes the confirmed changes in an edit without committing it. The changes are planned again in
+//the edit first, since the preview used an edit of its own, and nothing is changed unless they're the same.
+func applyMetadata(ctx context.Context, service *androidpublisher.Service, pkgName, edit string, params []*Param, confirmed *metadata.Plan) (*MetadataResult, error) {
+	p, err := planMetadata(ctx, service, pkgName, edit, params)
+	if err != nil {
+		return nil, err
+	}
+	if !p.Same(confirmed) {
+		return nil, errors.New("the store or the metadata directory changed since the preview, nothing was changed")
+	}
+	result := &MetadataResult{Dir: params[0].Value, Edit: edit, Locales: len(p.Locales)}
+	progress := Progress{Total: p.Len()}
+	done := func(n int) {
+		progress.Done += n
+		reportProgress(ctx, progress)
+	}
+	listings := androidpublisher.NewEditsListingsService(service)
+	s := androidpublisher.NewEditsImagesService(service)
+	for _, l := range p.Locales {
+		if l.Listing != nil {
+			if _, err := listings.Update(pkgName, edit, l.Language, l.Listing).Context(ctx).Do(); err != nil {
+				return nil, errors.Wrapf(err, "%v listing", l.Language)
+			}
+			result.Listings++
+			done(len(l.Texts))
+		}
+		for _, c := range l.Images {
+			if c.Remove > 0 {
+				if _, err := s.Deleteall(pkgName, edit, l.Language, c.Type).Context(ctx).Do(); err != nil {
+					return nil, errors.Wrapf(err, "%v %v", l.Language, c.Type)
+				}
+			}
+			infos, err := images.Validate(c.Type, c.Files, 0)
+			if err != nil {
+				return nil, err
+			}
+			for _, info := range infos {
+				if _, err := uploadImage(ctx, s, pkgName, edit, l.Language, c.Type, info); err != nil {
+					return nil, errors.Wrap(err, l.Language)
+				}
+				result.Images++
+			}
+			done(1)
+		}
+		result.ReleaseNotes += len(l.Notes)
+	}
+	if p.Track != nil {
+		if _, err := androidpublisher.NewEditsTracksService(service).Update(pkgName, edit, p.Track.Track, p.Track).Context(ctx).Do(); err != nil {
+			return nil, errors.Wrapf(err, "release notes of %v", p.Track.Track)
+		}
+		done(result.ReleaseNotes)
+	}
+	return result, nil
+}
+
+//downloadMetadataOperation writes the live listings, images and release notes to the metadata directory
+func downloadMetadataOperation(service *androidpublisher.Service, pkgName string) *Operation {
+	edits := androidpublisher.NewEditsService(service)
+	return &Operation{
+		Name:    "Download",
+		Params:  []*Param{{Name: "Dir(e.g. metadata/android)", Required: true}, {Name: "Track(default: production)"}},
+		Timeout: bulkTimeout,
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
+			edit, err := edits.Insert(pkgName, &androidpublisher.AppEdit{}).Context(ctx).Do()
+			if err != nil {
+				return nil, err
+			}
+			//the edit is only read, it's never committed
+			defer edits.Delete(pkgName, edit.Id).Context(context.Background()).Do()
+			store, err := loadStore(ctx, service, pkgName, edit.Id, trackParam(params[1]), nil)
+			if err != nil {
+				return nil, err
+			}
+			dir := params[0].Value
+			locales := metadata.FromStore(store)
+			if err := metadata.Write(dir, locales); err != nil {
+				return nil, err
+			}
+			result := &MetadataResult{Dir: dir, Locales: len(locales), Listings: len(store.Listings)}
+			s := androidpublisher.NewEditsImagesService(service)
+			live := map[string]string{}
+			progress := Progress{Total: len(store.Listings) * len(images.Types())}
+			for language := range store.Listings {
+				for _, t := range images.Types() {
+					res, err := s.List(pkgName, edit.Id, language, t).Context(ctx).Do()
+					if err != nil {
+						return nil, errors.Wrapf(err, "%v %v", language, t)
+					}
+					if err := removeImages(dir, language, t); err != nil {
+						return nil, err
+					}
+					for i, img := range res.Images {
+						hash, err := downloadImage(ctx, img, metadata.ImagePath(dir, language, t, i, ""))
+						if err != nil {
+							return nil, errors.Wrapf(err, "%v %v", language, t)
+						}
+						live[hash] = img.Sha256
+						result.Images++
+					}
+					progress.Done++
+					reportProgress(ctx, progress)
+				}
+			}
+			if err := metadata.WriteLiveHashes(dir, live); err != nil {
+				return nil, err
+			}
+			return result, nil
+		},
+	}
+}
+
+//removeImages removes the local images of a type, so images that aren't in the store don't linger
+func removeImages(dir, language, imageType string) error {
+	if images.Specs[imageType].MaxCount > 1 {
+		files, err := images.Files(filepath.Dir(metadata.ImagePath(dir, language, imageType, 0, "")))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		for _, f := range files {
+			if err := os.Remove(f); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, ext := range []string{".png", ".jpg", ".jpeg"} {
+		if err := os.Remove(metadata.ImagePath(dir, language, imageType, 0, ext)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+//downloadImage saves an image to path plus the extension of its format and returns the hash of the saved copy,
+//which differs from the hash of the image in the store since it's served re-encoded
+func downloadImage(ctx context.Context, img *androidpublisher.Image, path string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, img.Url, nil)
+	if err != nil {
+		return "", err
+	}
+	res, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", errors.Errorf("downloading %v: %v", img.Url, res.Status)
+	}
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	ext := ".png"
+	if http.DetectContentType(data) == "image/jpeg" {
+		ext = ".jpg"
+	}
+	path += ext
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		return "", err
+	}
+	return metadata.Hash(data), nil
+}
@@ -0,0 +1,225 @@
+//Package metadata reads and writes store metadata kept in a fastlane style directory tree and plans the changes
+//needed to make the store match it. The tree has a directory per locale:
+//
+//	<locale>/title.txt, short_description.txt, full_description.txt, video.txt
+//	<locale>/changelogs/<versionCode>.txt, changelogs/default.txt
+//	<locale>/images/icon.png, featureGraphic.png, ..., phoneScreenshots/*.png, ...
+package metadata
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hassansin/androidpublisher/images"
+	"github.com/pkg/errors"
+)
+
+//Listing fields
+const (
+	Title            = "title"
+	ShortDescription = "shortDescription"
+	FullDescription  = "fullDescription"
+	Video            = "video"
+)
+
+//liveHashesFile records the hashes of downloaded images. The store serves re-encoded copies of images, so without
+//it a downloaded image wouldn't match the one in the store.
+const liveHashesFile = ".live-hashes.json"
+
+//DefaultChangelog is the key of the release notes used for version codes without their own file
+const DefaultChangelog = "default"
+
+//Fields are the listing fields in the order they're shown
+var Fields = []string{Title, ShortDescription, FullDescription, Video}
+
+var fieldFiles = map[string]string{
+	Title:            "title.txt",
+	ShortDescription: "short_description.txt",
+	FullDescription:  "full_description.txt",
+	Video:            "video.txt",
+}
+
+//Locale is the metadata of one language
+type Locale struct {
+	Language string
+	//Texts are the listing texts by field, fields without a file are left out
+	Texts map[string]string
+	//Changelogs are the release notes by version code or DefaultChangelog
+	Changelogs map[string]string
+	//Images are the image files by image type, in upload order
+	Images map[string][]string
+	//LiveHashes are the hashes of downloaded images in the store by the hashes of their local copies
+	LiveHashes map[string]string
+}
+
+//Read reads every locale directory under dir
+func Read(dir string) ([]*Locale, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	live, err := ReadLiveHashes(dir)
+	if err != nil {
+		return nil, err
+	}
+	var locales []*Locale
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		l, err := readLocale(filepath.Join(dir, e.Name()), e.Name())
+		if err != nil {
+			return nil, err
+		}
+		l.LiveHashes = live
+		locales = append(locales, l)
+	}
+	return locales, nil
+}
+
+//ReadLiveHashes returns the hashes of downloaded images in the store by the hashes of their local copies
+func ReadLiveHashes(dir string) (map[string]string, error) {
+	live := map[string]string{}
+	data, err := ioutil.ReadFile(filepath.Join(dir, liveHashesFile))
+	if os.IsNotExist(err) {
+		return live, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &live); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse %v", liveHashesFile)
+	}
+	return live, nil
+}
+
+//WriteLiveHashes records the hashes of downloaded images in the store by the hashes of their local copies
+func WriteLiveHashes(dir string, live map[string]string) error {
+	data, err := json.MarshalIndent(live, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, liveHashesFile), data, 0644)
+}
+
+func readLocale(dir, language string) (*Locale, error) {
+	l := &Locale{Language: language, Texts: map[string]string{}, Changelogs: map[string]string{}, Images: map[string][]string{}}
+	for field, name := range fieldFiles {
+		text, ok, err := readText(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			l.Texts[field] = text
+		}
+	}
+
+	changelogs, err := ioutil.ReadDir(filepath.Join(dir, "changelogs"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, e := range changelogs {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".txt" {
+			continue
+		}
+		text, _, err := readText(filepath.Join(dir, "changelogs", e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		l.Changelogs[strings.TrimSuffix(e.Name(), ".txt")] = text
+	}
+
+	imageDir := filepath.Join(dir, "images")
+	for _, t := range images.Types() {
+		if images.Specs[t].MaxCount > 1 {
+			files, err := images.Files(filepath.Join(imageDir, t))
+			if err != nil && !os.IsNotExist(err) {
+				return nil, err
+			}
+			if len(files) > 0 {
+				l.Images[t] = files
+			}
+			continue
+		}
+		for _, ext := range []string{".png", ".jpg", ".jpeg"} {
+			path := filepath.Join(imageDir, t+ext)
+			if _, err := os.Stat(path); err == nil {
+				l.Images[t] = []string{path}
+				break
+			}
+		}
+	}
+	return l, nil
+}
+
+//readText reads a text file without surrounding whitespace, ok is false when the file doesn't exist
+func readText(path string) (text string, ok bool, err error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return strings.TrimSpace(string(data)), true, nil
+}
+
+//Write writes the texts and release notes of the locales under dir. Images are written with ImagePath.
+func Write(dir string, locales []*Locale) error {
+	for _, l := range locales {
+		for field, text := range l.Texts {
+			if err := writeText(filepath.Join(dir, l.Language, fieldFiles[field]), text); err != nil {
+				return err
+			}
+		}
+		for name, text := range l.Changelogs {
+			if err := writeText(filepath.Join(dir, l.Language, "changelogs", name+".txt"), text); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func writeText(path, text string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return errors.Wrap(ioutil.WriteFile(path, []byte(text+"\n"), 0644), path)
+}
+
+//ImagePath returns where the image of a type at index is kept, screenshots are numbered from 1
+func ImagePath(dir, language, imageType string, index int, ext string) string {
+	if images.Specs[imageType].MaxCount > 1 {
+		return filepath.Join(dir, language, "images", imageType, strconv.Itoa(index+1)+ext)
+	}
+	return filepath.Join(dir, language, "images", imageType+ext)
+}
+
+//Hashes returns the hex encoded SHA-256 hashes of files, the way the Play Store reports them for images
+func Hashes(files []string) ([]string, error) {
+	var hashes []string
+	for _, path := range files {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, Hash(data))
+	}
+	return hashes, nil
+}
+
+//Hash returns the hex encoded SHA-256 hash of data
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,251 @@
+package metadata
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	androidpublisher "google.golang.org/api/androidpublisher/v3"
+)
+
+func write(t *testing.T, path, content string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRead(t *testing.T) {
+	dir := t.TempDir()
+	write(t, filepath.Join(dir, "en-US", "title.txt"), "My App\n")
+	write(t, filepath.Join(dir, "en-US", "full_description.txt"), "Does things.\n\nAnd more.\n")
+	write(t, filepath.Join(dir, "en-US", "changelogs", "42.txt"), "Bug fixes\n")
+	write(t, filepath.Join(dir, "en-US", "changelogs", "default.txt"), "Improvements\n")
+	write(t, filepath.Join(dir, "en-US", "images", "icon.png"), "icon")
+	write(t, filepath.Join(dir, "en-US", "images", "phoneScreenshots", "2.png"), "b")
+	write(t, filepath.Join(dir, "en-US", "images", "phoneScreenshots", "1.png"), "a")
+	write(t, filepath.Join(dir, "de-DE", "short_description.txt"), "Macht Dinge")
+	write(t, filepath.Join(dir, ".git", "title.txt"), "ignored")
+
+	locales, err := Read(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(locales) != 2 || locales[0].Language != "de-DE" || locales[1].Language != "en-US" {
+		t.Fatalf("unexpected locales %+v", locales)
+	}
+	if want := map[string]string{ShortDescription: "Macht Dinge"}; !reflect.DeepEqual(locales[0].Texts, want) {
+		t.Errorf("de-DE texts = %v, expected %v", locales[0].Texts, want)
+	}
+	en := locales[1]
+	if want := map[string]string{Title: "My App", FullDescription: "Does things.\n\nAnd more."}; !reflect.DeepEqual(en.Texts, want) {
+		t.Errorf("en-US texts = %v, expected %v", en.Texts, want)
+	}
+	if want := map[string]string{"42": "Bug fixes", DefaultChangelog: "Improvements"}; !reflect.DeepEqual(en.Changelogs, want) {
+		t.Errorf("changelogs = %v, expected %v", en.Changelogs, want)
+	}
+	shots := en.Images["phoneScreenshots"]
+	if len(shots) != 2 || filepath.Base(shots[0]) != "1.png" || len(en.Images["icon"]) != 1 {
+		t.Errorf("unexpected images %v", en.Images)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	dir := t.TempDir()
+	shot := filepath.Join(dir, "1.png")
+	write(t, shot, "screenshot")
+	hashes, err := Hashes([]string{shot})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	locales := []*Locale{
+		{
+			Language:   "en-US",
+			Texts:      map[string]string{Title: "New title", ShortDescription: "Same"},
+			Changelogs: map[string]string{"11": "Notes for 11", DefaultChangelog: "Default notes"},
+			Images:     map[string][]string{"phoneScreenshots": {shot}, "featureGraphic": {shot}},
+		},
+		{Language: "fr-FR", Texts: map[string]string{Title: "Titre"}},
+	}
+	store := &Store{
+		Listings: map[string]*androidpublisher.Listing{
+			"en-US": {Language: "en-US", Title: "Old title", ShortDescription: "Same", FullDescription: "Kept"},
+		},
+		Images: map[string]map[string][]string{"en-US": {"phoneScreenshots": hashes, "featureGraphic": {"old"}}},
+		Track: &androidpublisher.Track{Track: "production", Releases: []*androidpublisher.TrackRelease{
+			{Name: "1.0", Status: "completed", VersionCodes: []int64{10}, ReleaseNotes: []*androidpublisher.LocalizedText{{Language: "en-US", Text: "Old"}}},
+			{Name: "1.1", Status: "inProgress", VersionCodes: []int64{11}},
+			{Name: "1.2", Status: "draft", VersionCodes: []int64{12}, ReleaseNotes: []*androidpublisher.LocalizedText{{Language: "en-US", Text: "Default notes"}}},
+		}},
+	}
+
+	plan, err := Compare(locales, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Locales) != 2 {
+		t.Fatalf("expected changes to 2 locales, got %+v", plan.Locales)
+	}
+	en, fr := plan.Locales[0], plan.Locales[1]
+	if want := []TextChange{{Field: Title, From: "Old title", To: "New title"}}; !reflect.DeepEqual(en.Texts, want) {
+		t.Errorf("texts = %+v, expected %+v", en.Texts, want)
+	}
+	if en.Listing == nil || en.Listing.Title != "New title" || en.Listing.FullDescription != "Kept" {
+		t.Errorf("unexpected listing %+v", en.Listing)
+	}
+	if want := []ImageChange{{Type: "featureGraphic", Remove: 1, Files: []string{shot}, Hashes: hashes}}; !reflect.DeepEqual(en.Images, want) {
+		t.Errorf("images = %+v, expected %+v", en.Images, want)
+	}
+	//the completed release keeps its notes and the draft has the default notes already
+	if want := []NotesChange{{Release: "1.1", VersionCode: 11, To: "Notes for 11"}}; !reflect.DeepEqual(en.Notes, want) {
+		t.Errorf("notes = %+v, expected %+v", en.Notes, want)
+	}
+	if fr.Listing == nil || fr.Listing.Language != "fr-FR" || fr.Listing.Title != "Titre" {
+		t.Errorf("unexpected new listing %+v", fr.Listing)
+	}
+	if plan.Len() != 4 {
+		t.Errorf("Len = %v, expected 4", plan.Len())
+	}
+
+	if plan.Track == nil || plan.Track.Releases[1].ReleaseNotes[0].Text != "Notes for 11" {
+		t.Errorf("unexpected track %+v", plan.Track)
+	}
+	if store.Track.Releases[1].ReleaseNotes != nil || store.Listings["en-US"].Title != "Old title" {
+		t.Error("Compare changed the store")
+	}
+
+	summary := plan.Summary()
+	want := []string{`title: "Old title" → "New title"`, `release notes of 11 (1.1): set to "Notes for 11"`, "featureGraphic: replace 1 images with 1"}
+	if !reflect.DeepEqual(summary["en-US"], want) {
+		t.Errorf("summary = %q, expected %q", summary["en-US"], want)
+	}
+}
+
+func TestCompareUnchanged(t *testing.T) {
+	locales := []*Locale{{Language: "en-US", Texts: map[string]string{Title: "Title"}, Changelogs: map[string]string{"5": "Notes"}}}
+	store := &Store{
+		Listings: map[string]*androidpublisher.Listing{"en-US": {Language: "en-US", Title: "Title"}},
+		Track: &androidpublisher.Track{Releases: []*androidpublisher.TrackRelease{
+			{Status: "completed", VersionCodes: []int64{5}, ReleaseNotes: []*androidpublisher.LocalizedText{{Language: "en-US", Text: "Notes"}}},
+		}},
+	}
+	plan, err := Compare(locales, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Len() != 0 || plan.Track != nil {
+		t.Errorf("expected no changes, got %+v", plan)
+	}
+}
+
+func TestDownloadRoundTrip(t *testing.T) {
+	store := &Store{
+		Listings: map[string]*androidpublisher.Listing{
+			"en-US": {Language: "en-US", Title: "Title", FullDescription: "Line 1\nLine 2"},
+		},
+		Track: &androidpublisher.Track{Releases: []*androidpublisher.TrackRelease{
+			{VersionCodes: []int64{7, 8}, ReleaseNotes: []*androidpublisher.LocalizedText{{Language: "en-US", Text: "Notes"}}},
+		}},
+	}
+	dir := t.TempDir()
+	if err := Write(dir, FromStore(store)); err != nil {
+		t.Fatal(err)
+	}
+	locales, err := Read(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(locales) != 1 || locales[0].Changelogs["8"] != "Notes" {
+		t.Fatalf("unexpected locales %+v", locales)
+	}
+	plan, err := Compare(locales, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Len() != 0 {
+		t.Errorf("expected no changes after a download, got %v", plan.Summary())
+	}
+}
+
+func TestLiveHashes(t *testing.T) {
+	dir := t.TempDir()
+	write(t, filepath.Join(dir, "en-US", "images", "phoneScreenshots", "1.png"), "downloaded copy")
+	write(t, filepath.Join(dir, "en-US", "images", "phoneScreenshots", "2.png"), "new screenshot")
+	if err := WriteLiveHashes(dir, map[string]string{Hash([]byte("downloaded copy")): "live"}); err != nil {
+		t.Fatal(err)
+	}
+	locales, err := Read(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &Store{
+		Listings: map[string]*androidpublisher.Listing{"en-US": {Language: "en-US"}},
+		Images:   map[string]map[string][]string{"en-US": {"phoneScreenshots": {"live"}}},
+	}
+	plan, err := Compare(locales, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//the downloaded copy matches, but there's a screenshot to add
+	if plan.Len() != 1 || plan.Locales[0].Images[0].Remove != 1 || len(plan.Locales[0].Images[0].Files) != 2 {
+		t.Fatalf("unexpected plan %+v", plan.Summary())
+	}
+
+	store.Images["en-US"]["phoneScreenshots"] = []string{"live", Hash([]byte("new screenshot"))}
+	if plan, err = Compare(locales, store); err != nil {
+		t.Fatal(err)
+	}
+	if plan.Len() != 0 {
+		t.Errorf("expected no changes, got %v", plan.Summary())
+	}
+}
+
+func TestSame(t *testing.T) {
+	dir := t.TempDir()
+	shot := filepath.Join(dir, "icon.png")
+	write(t, shot, "v1")
+	locales := []*Locale{{Language: "en-US", Texts: map[string]string{Title: "Title"}, Images: map[string][]string{"icon": {shot}}}}
+	store := &Store{Listings: map[string]*androidpublisher.Listing{}}
+	confirmed, err := Compare(locales, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := Compare(locales, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !confirmed.Same(again) {
+		t.Error("expected the same plan")
+	}
+
+	//the summary stays the same when the icon is replaced, the plan doesn't
+	write(t, shot, "v2")
+	changed, err := Compare(locales, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if confirmed.Same(changed) || !reflect.DeepEqual(confirmed.Summary(), changed.Summary()) {
+		t.Error("expected a different plan with the same summary")
+	}
+	store.Listings["en-US"] = &androidpublisher.Listing{Language: "en-US", Title: "Title"}
+	if changed, err = Compare(locales, store); err != nil {
+		t.Fatal(err)
+	}
+	if confirmed.Same(changed) {
+		t.Error("expected a different plan after the store changed")
+	}
+}
+
+func TestImagePath(t *testing.T) {
+	if got := ImagePath("m", "en-US", "phoneScreenshots", 0, ".png"); got != filepath.Join("m", "en-US", "images", "phoneScreenshots", "1.png") {
+		t.Errorf("unexpected screenshot path %v", got)
+	}
+	if got := ImagePath("m", "en-US", "icon", 0, ".png"); got != filepath.Join("m", "en-US", "images", "icon.png") {
+		t.Errorf("unexpected icon path %v", got)
+	}
+}
@@ -0,0 +1,323 @@
+package metadata
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hassansin/androidpublisher/images"
+	androidpublisher "google.golang.org/api/androidpublisher/v3"
+)
+
+//maxQuoted is the length up to which changed texts are quoted in the summary instead of counted
+const maxQuoted = 80
+
+//Store is the live metadata in an edit
+type Store struct {
+	//Listings are the listings by language
+	Listings map[string]*androidpublisher.Listing
+	//Images are the SHA-256 hashes of the images by language and image type
+	Images map[string]map[string][]string
+	//Track has the releases whose notes are synced, nil to leave release notes alone
+	Track *androidpublisher.Track
+}
+
+//TextChange is a changed listing field
+type TextChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+//NotesChange is changed release notes
+type NotesChange struct {
+	Release     string `json:"release"`
+	VersionCode int64  `json:"versionCode"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+}
+
+//ImageChange replaces the images of a type
+type ImageChange struct {
+	Type   string   `json:"type"`
+	Remove int      `json:"remove"`
+	Files  []string `json:"files"`
+	//Hashes are the SHA-256 hashes of the files when they were compared
+	Hashes []string `json:"hashes"`
+}
+
+//LocalePlan lists the changes to one language
+type LocalePlan struct {
+	Language string `json:"language"`
+	//Listing is the listing to save, nil when it's unchanged
+	Listing *androidpublisher.Listing `json:"-"`
+	Texts   []TextChange              `json:"texts,omitempty"`
+	Notes   []NotesChange             `json:"notes,omitempty"`
+	Images  []ImageChange             `json:"images,omitempty"`
+}
+
+//Len returns the number of changes
+func (l *LocalePlan) Len() int {
+	return len(l.Texts) + len(l.Notes) + len(l.Images)
+}
+
+//Plan lists the changes needed to make the store match the local metadata
+type Plan struct {
+	Locales []*LocalePlan
+	//Track is the track with the new release notes, nil when they're unchanged
+	Track *androidpublisher.Track
+}
+
+//Len returns the number of changes
+func (p *Plan) Len() int {
+	n := 0
+	for _, l := range p.Locales {
+		n += l.Len()
+	}
+	return n
+}
+
+//Same reports whether two plans make the same changes, with the same local files
+func (p *Plan) Same(other *Plan) bool {
+	if len(p.Locales) != len(other.Locales) {
+		return false
+	}
+	for i, l := range p.Locales {
+		o := other.Locales[i]
+		if l.Language != o.Language || !reflect.DeepEqual(l.Texts, o.Texts) || !reflect.DeepEqual(l.Notes, o.Notes) ||
+			!reflect.DeepEqual(l.Images, o.Images) {
+			return false
+		}
+	}
+	return true
+}
+
+//Summary describes the changes per language in a line each
+func (p *Plan) Summary() map[string][]string {
+	summary := map[string][]string{}
+	for _, l := range p.Locales {
+		var lines []string
+		for _, c := range l.Texts {
+			lines = append(lines, fmt.Sprintf("%v: %v", c.Field, describe(c.From, c.To)))
+		}
+		for _, c := range l.Notes {
+			lines = append(lines, fmt.Sprintf("release notes of %v (%v): %v", c.VersionCode, c.Release, describe(c.From, c.To)))
+		}
+		for _, c := range l.Images {
+			if c.Remove == 0 {
+				lines = append(lines, fmt.Sprintf("%v: add %v images", c.Type, len(c.Files)))
+			} else {
+				lines = append(lines, fmt.Sprintf("%v: replace %v images with %v", c.Type, c.Remove, len(c.Files)))
+			}
+		}
+		summary[l.Language] = lines
+	}
+	return summary
+}
+
+func describe(from, to string) string {
+	if len(from) > maxQuoted || len(to) > maxQuoted {
+		return fmt.Sprintf("%v → %v characters", utf8.RuneCountInString(from), utf8.RuneCountInString(to))
+	}
+	if from == "" {
+		return fmt.Sprintf("set to %q", to)
+	}
+	return fmt.Sprintf("%q → %q", from, to)
+}
+
+//Compare plans the changes that make store match locales. Only what's in the local tree is compared: missing
+//files leave the live listing fields, images and release notes as they are.
+func Compare(locales []*Locale, store *Store) (*Plan, error) {
+	plan := &Plan{}
+	var track *androidpublisher.Track
+	if store.Track != nil {
+		track = copyTrack(store.Track)
+	}
+	notesChanged := false
+	for _, l := range locales {
+		lp := &LocalePlan{Language: l.Language}
+
+		listing := &androidpublisher.Listing{Language: l.Language}
+		if live := store.Listings[l.Language]; live != nil {
+			*listing = *live
+		}
+		for _, field := range Fields {
+			text, ok := l.Texts[field]
+			if !ok {
+				continue
+			}
+			if from := listingField(listing, field); from != text {
+				lp.Texts = append(lp.Texts, TextChange{Field: field, From: from, To: text})
+				setListingField(listing, field, text)
+			}
+		}
+		if len(lp.Texts) > 0 {
+			lp.Listing = listing
+		}
+
+		for _, t := range images.Types() {
+			files := l.Images[t]
+			if len(files) == 0 {
+				continue
+			}
+			hashes, err := Hashes(files)
+			if err != nil {
+				return nil, err
+			}
+			compared := make([]string, len(hashes))
+			for i, h := range hashes {
+				compared[i] = h
+				//a downloaded copy stands for the image it was downloaded from
+				if live, ok := l.LiveHashes[h]; ok {
+					compared[i] = live
+				}
+			}
+			live := store.Images[l.Language][t]
+			if strings.Join(compared, ",") != strings.Join(live, ",") {
+				lp.Images = append(lp.Images, ImageChange{Type: t, Remove: len(live), Files: files, Hashes: hashes})
+			}
+		}
+
+		if track != nil {
+			for _, r := range track.Releases {
+				text, ok := releaseNotes(l, r)
+				if !ok {
+					continue
+				}
+				from := ""
+				var notes *androidpublisher.LocalizedText
+				for _, n := range r.ReleaseNotes {
+					if n.Language == l.Language {
+						notes, from = n, n.Text
+					}
+				}
+				if from == text {
+					continue
+				}
+				if notes == nil {
+					r.ReleaseNotes = append(r.ReleaseNotes, &androidpublisher.LocalizedText{Language: l.Language, Text: text})
+				} else {
+					notes.Text = text
+				}
+				lp.Notes = append(lp.Notes, NotesChange{Release: r.Name, VersionCode: maxVersionCode(r), From: from, To: text})
+				notesChanged = true
+			}
+		}
+
+		if lp.Len() > 0 {
+			plan.Locales = append(plan.Locales, lp)
+		}
+	}
+	sort.Slice(plan.Locales, func(i, j int) bool { return plan.Locales[i].Language < plan.Locales[j].Language })
+	if notesChanged {
+		plan.Track = track
+	}
+	return plan, nil
+}
+
+//releaseNotes returns the notes of a language for a release: the changelog of its highest version code that has
+//one, or the default changelog unless the release is completed already
+func releaseNotes(l *Locale, r *androidpublisher.TrackRelease) (string, bool) {
+	codes := append([]int64(nil), r.VersionCodes...)
+	sort.Slice(codes, func(i, j int) bool { return codes[i] > codes[j] })
+	for _, code := range codes {
+		if text, ok := l.Changelogs[strconv.FormatInt(code, 10)]; ok {
+			return text, true
+		}
+	}
+	if r.Status == "completed" {
+		return "", false
+	}
+	text, ok := l.Changelogs[DefaultChangelog]
+	return text, ok
+}
+
+func maxVersionCode(r *androidpublisher.TrackRelease) int64 {
+	var max int64
+	for _, code := range r.VersionCodes {
+		if code > max {
+			max = code
+		}
+	}
+	return max
+}
+
+//copyTrack copies a track deep enough to change the release notes
+func copyTrack(t *androidpublisher.Track) *androidpublisher.Track {
+	c := *t
+	c.Releases = nil
+	for _, r := range t.Releases {
+		rc := *r
+		rc.ReleaseNotes = nil
+		for _, n := range r.ReleaseNotes {
+			nc := *n
+			rc.ReleaseNotes = append(rc.ReleaseNotes, &nc)
+		}
+		c.Releases = append(c.Releases, &rc)
+	}
+	return &c
+}
+
+//FromStore returns the texts and release notes of a store as locales, the reverse of Compare
+func FromStore(store *Store) []*Locale {
+	byLanguage := map[string]*Locale{}
+	locale := func(language string) *Locale {
+		if l, ok := byLanguage[language]; ok {
+			return l
+		}
+		l := &Locale{Language: language, Texts: map[string]string{}, Changelogs: map[string]string{}, Images: map[string][]string{}}
+		byLanguage[language] = l
+		return l
+	}
+	for language, listing := range store.Listings {
+		l := locale(language)
+		//empty fields are written too, so that a stale file can't bring back a removed text
+		for _, field := range Fields {
+			l.Texts[field] = listingField(listing, field)
+		}
+	}
+	if store.Track != nil {
+		for _, r := range store.Track.Releases {
+			code := strconv.FormatInt(maxVersionCode(r), 10)
+			for _, n := range r.ReleaseNotes {
+				locale(n.Language).Changelogs[code] = n.Text
+			}
+		}
+	}
+	var locales []*Locale
+	for _, l := range byLanguage {
+		locales = append(locales, l)
+	}
+	sort.Slice(locales, func(i, j int) bool { return locales[i].Language < locales[j].Language })
+	return locales
+}
+
+func listingField(l *androidpublisher.Listing, field string) string {
+	switch field {
+	case Title:
+		return l.Title
+	case ShortDescription:
+		return l.ShortDescription
+	case FullDescription:
+		return l.FullDescription
+	case Video:
+		return l.Video
+	}
+	return ""
+}
+
+func setListingField(l *androidpublisher.Listing, field, text string) {
+	switch field {
+	case Title:
+		l.Title = text
+	case ShortDescription:
+		l.ShortDescription = text
+	case FullDescription:
+		l.FullDescription = text
+	case Video:
+		l.Video = text
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/hassansin/androidpublisher/orders"
+	"github.com/hassansin/androidpublisher/rtdn"
+	"github.com/hassansin/androidpublisher/ui"
+	"github.com/hassansin/gocui"
+	"github.com/logrusorgru/aurora"
+	"github.com/spf13/viper"
+)
+
+var (
+	//notifications are the real-time developer notifications received so far, newest first
+	notifications     []rtdn.Notification
+	notificationsMu   sync.Mutex
+	notificationsList *ui.List
+	//shownNotifications are the notifications in the order the panel lists them, new ones may have arrived since
+	shownNotifications []rtdn.Notification
+)
+
+//receiveNotifications serves Pub/Sub push requests that carry token on addr and shows the notifications as they
+//arrive
+func receiveNotifications(g *gocui.Gui, addr, token string) {
+	handler := rtdn.Authorize(token, rtdn.Handler(func(n rtdn.Notification) {
+		notificationsMu.Lock()
+		notifications = append([]rtdn.Notification{n}, notifications...)
+		notificationsMu.Unlock()
+		err := recordNotification(n.Developer)
+		g.Update(func(g *gocui.Gui) error {
+			if err != nil {
+				status.UpdateError(err.Error())
+				return nil
+			}
+			status.UpdateSuccess(fmt.Sprintf("RTDN %v %v %v:Notifications", n.Developer.Type(), n.Developer.Subject(), aurora.Cyan("F6")))
+			if notificationsList != nil {
+				notificationsList.SetItems(notificationItems())
+			}
+			return nil
+		})
+	}))
+	go func() {
+		if err := http.ListenAndServe(addr, handler); err != nil {
+			g.Update(func(g *gocui.Gui) error {
+				status.UpdateError(fmt.Sprintf("RTDN receiver stopped: %v", err))
+				return nil
+			})
+		}
+	}()
+}
+
+func receivedNotifications() []rtdn.Notification {
+	notificationsMu.Lock()
+	defer notificationsMu.Unlock()
+	return append([]rtdn.Notification(nil), notifications...)
+}
+
+//notificationItems takes a snapshot of the notifications for the panel and returns its items
+func notificationItems() []string {
+	shownNotifications = receivedNotifications()
+	items := make([]string, len(shownNotifications))
+	for i, n := range shownNotifications {
+		items[i] = n.String()
+	}
+	return items
+}
+
+//showNotifications opens the notifications panel, picking a notification shows it decoded
+func showNotifications(g *gocui.Gui, v *gocui.View) error {
+	if notificationsList != nil {
+		return nil
+	}
+	if len(receivedNotifications()) == 0 {
+		if addr := viper.GetString("rtdn-listen"); addr == "" {
+			status.Update("The RTDN receiver is off, start it with --rtdn-listen")
+		} else {
+			status.Update(fmt.Sprintf("No notifications have been received on %v yet", addr))
+		}
+		return nil
+	}
+	current := g.CurrentView()
+	restore := func() error {
+		if current == nil {
+			return sideView.SetCurrent()
+		}
+		_, err := g.SetCurrentView(current.Name())
+		return err
+	}
+	notificationsList = ui.NewList(g, "Notifications", notificationItems()).OnSelect(func(idx int) error {
+		notificationsList = nil
+		n := shownNotifications[idx]
+		body, _ := json.MarshalIndent(n, "", " ")
+		mainView.Load(mainView.NewTab(), "RTDN "+n.Developer.Type(), ui.Text(body))
+		return mainView.SetCurrent()
+	}).OnCancel(func() error {
+		notificationsList = nil
+		return restore()
+	}).Bind('g', func(idx int) error {
+		n := shownNotifications[idx]
+		grp, op, params := lookupPurchase(n.Developer)
+		if op == nil {
+			status.UpdateError(fmt.Sprintf("%v notifications don't reference a purchase that can be looked up", n.Developer.Type()))
+			return nil
+		}
+		if err := notificationsList.Close(); err != nil {
+			return err
+		}
+		notificationsList = nil
+		jobs.StartWith(grp, op, params, mainView.NewTab())
+		return mainView.SetCurrent()
+	})
+	status.Update(fmt.Sprintf("%v:Show Notification %v:Get Purchase %v:Close", aurora.Cyan("ENTER"), aurora.Cyan("g"), aurora.Cyan("ESC")))
+	return notificationsList.Show()
+}
+
+//recordNotification adds the order of a voided purchase notification to the order index
+func recordNotification(n rtdn.DeveloperNotification) error {
+	v := n.VoidedPurchaseNotification
+	if v == nil {
+		return nil
+	}
+	kind := ""
+	switch v.ProductType {
+	case 1:
+		kind = orders.Subscription
+	case 2:
+		kind = orders.Product
+	}
+	return recordOrders(orders.Purchase{OrderID: v.OrderID, Kind: kind, Token: v.PurchaseToken, Source: "notification"})
+}
+
+//lookupPurchase returns the operation and parameters that get the purchase referenced by a notification
+func lookupPurchase(n rtdn.DeveloperNotification) (*Group, *Operation, []*Param) {
+	var name, id, token string
+	switch {
+	case n.SubscriptionNotification != nil:
+		name, id, token = "Purchases.subscriptions", n.SubscriptionNotification.SubscriptionID, n.SubscriptionNotification.PurchaseToken
+	case n.OneTimeProductNotification != nil:
+		name, id, token = "Purchases.products", n.OneTimeProductNotification.Sku, n.OneTimeProductNotification.PurchaseToken
+	default:
+		return nil, nil, nil
+	}
+	op := groups.Find(name + ".Get")
+	params := op.CopyParams()
+	params[0].Value, params[1].Value = id, token
+	return groups.Group(name), op, params
+}
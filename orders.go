@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/hassansin/androidpublisher/apierror"
+	"github.com/hassansin/androidpublisher/orders"
+	"github.com/hassansin/androidpublisher/ui"
+	"github.com/hassansin/androidpublisher/voided"
+	"github.com/hassansin/gocui"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	androidpublisher "google.golang.org/api/androidpublisher/v3"
+)
+
+//orderIndex is the index of the orders of the package, it's opened when first used
+var orderIndex struct {
+	sync.Mutex
+	index *orders.Index
+}
+
+//recordOrders adds purchases to the order index and saves it if anything changed
+func recordOrders(purchases ...orders.Purchase) error {
+	orderIndex.Lock()
+	defer orderIndex.Unlock()
+	if orderIndex.index == nil {
+		index, err := orders.Open(filepath.Join(dataDir(), "orders-"+viper.GetString("package")+".json"))
+		if err != nil {
+			return err
+		}
+		orderIndex.index = index
+	}
+	changed := false
+	for _, p := range purchases {
+		changed = orderIndex.index.Add(p) || changed
+	}
+	if !changed {
+		return nil
+	}
+	return errors.Wrap(orderIndex.index.Save(), "unable to save order index")
+}
+
+//lookupOrders returns the indexed purchases of an order
+func lookupOrders(orderID string) ([]orders.Purchase, error) {
+	//recording nothing opens the index
+	if err := recordOrders(); err != nil {
+		return nil, err
+	}
+	orderIndex.Lock()
+	defer orderIndex.Unlock()
+	return orderIndex.index.Lookup(orderID), nil
+}
+
+//findToken returns the indexed purchase with the given token
+func findToken(token string) (orders.Purchase, bool, error) {
+	if err := recordOrders(); err != nil {
+		return orders.Purchase{}, false, err
+	}
+	orderIndex.Lock()
+	defer orderIndex.Unlock()
+	p, ok := orderIndex.index.FindToken(token)
+	return p, ok, nil
+}
+
+//recordJob adds the purchase returned by a Get operation to the order index
+func recordJob(job Job) error {
+	if job.Op.Name != "Get" {
+		return nil
+	}
+	source := job.Group.Name + ".Get"
+	switch res := job.Result.(type) {
+	case *androidpublisher.SubscriptionPurchase:
+		return recordOrders(orders.Purchase{OrderID: res.OrderId, Kind: orders.Subscription, ProductID: job.Params[0].Value, Token: job.Params[1].Value, Source: source})
+	case *androidpublisher.ProductPurchase:
+		return recordOrders(orders.Purchase{OrderID: res.OrderId, Kind: orders.Product, ProductID: job.Params[0].Value, Token: job.Params[1].Value, Source: source})
+	}
+	return nil
+}
+
+//OrderLookup is the result of looking up an order
+type OrderLookup struct {
+	OrderID   string          `json:"orderId"`
+	Purchases []OrderPurchase `json:"purchases"`
+}
+
+//OrderPurchase is a purchase related to an order with its current state
+type OrderPurchase struct {
+	orders.Purchase
+	Current interface{}                        `json:"current,omitempty"`
+	Error   string                             `json:"error,omitempty"`
+	Voided  []*androidpublisher.VoidedPurchase `json:"voided,omitempty"`
+}
+
+//lookupOrderOperation resolves an order ID to purchases through the local order index and voided purchases,
+//and gets their current state
+func lookupOrderOperation(service *androidpublisher.Service, pkgName string) *Operation {
+	return &Operation{
+		Name:   "Lookup",
+		Params: []*Param{{Name: "OrderID", Required: true}},
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
+			orderID := params[0].Value
+			voidedMu.Lock()
+			store, err := voided.Open(voidedPath(pkgName))
+			voidedMu.Unlock()
+			if err != nil {
+				return nil, err
+			}
+			voidedByOrder := map[string][]*androidpublisher.VoidedPurchase{}
+			for _, v := range store.Purchases {
+				if orders.BaseOrderID(v.OrderId) == orders.BaseOrderID(orderID) {
+					voidedByOrder[v.OrderId] = append(voidedByOrder[v.OrderId], v)
+					if err := recordOrders(orders.Purchase{OrderID: v.OrderId, Token: v.PurchaseToken, Source: "voided purchases"}); err != nil {
+						return nil, err
+					}
+				}
+			}
+			found, err := lookupOrders(orderID)
+			if err != nil {
+				return nil, err
+			}
+			if len(found) == 0 {
+				return nil, errors.Errorf("order %v isn't known locally, orders are recorded by Get, BulkGet, voided purchase syncs and notifications", orderID)
+			}
+
+			result := &OrderLookup{OrderID: orderID}
+			for _, p := range found {
+				op := OrderPurchase{Purchase: p, Voided: voidedByOrder[p.OrderID]}
+				switch {
+				case p.ProductID == "" || p.Token == "":
+				case p.Kind == orders.Subscription:
+					op.Current, err = androidpublisher.NewPurchasesSubscriptionsService(service).Get(pkgName, p.ProductID, p.Token).Context(ctx).Do()
+				case p.Kind == orders.Product:
+					op.Current, err = androidpublisher.NewPurchasesProductsService(service).Get(pkgName, p.ProductID, p.Token).Context(ctx).Do()
+				}
+				if err != nil {
+					op.Current, op.Error = nil, apierror.Summary(err)
+					err = nil
+				}
+				result.Purchases = append(result.Purchases, op)
+			}
+			return result, nil
+		},
+	}
+}
+
+//orderAction is an operation that can be run on a looked up purchase
+type orderAction struct {
+	op     string
+	values []string
+}
+
+func (a orderAction) String() string {
+	return fmt.Sprintf("%-32v %v", a.op, a.values[0])
+}
+
+//orderActions opens the refund, cancel and defer operations for a purchase of the order looked up in the current tab
+func orderActions(g *gocui.Gui, v *gocui.View) error {
+	lookup, ok := mainView.CurrentTab().Body().(*OrderLookup)
+	if !ok {
+		return nil
+	}
+	var actions []orderAction
+	for _, p := range lookup.Purchases {
+		actions = append(actions, orderAction{"Orders.Refund", []string{p.OrderID}})
+		if p.Kind == orders.Subscription && p.ProductID != "" && p.Token != "" {
+			for _, name := range []string{"Cancel", "Defer", "Refund", "Revoke"} {
+				actions = append(actions, orderAction{"Purchases.subscriptions." + name, []string{p.ProductID, p.Token}})
+			}
+		}
+	}
+	items := make([]string, len(actions))
+	for i, a := range actions {
+		items[i] = a.String()
+	}
+	return ui.NewList(g, "Actions", items).OnSelect(func(idx int) error {
+		a := actions[idx]
+		op := groups.Find(a.op)
+		for i, value := range a.values {
+			op.Params[i].Value = value
+		}
+		return showParams(g, groups.Group(a.op[:len(a.op)-len(op.Name)-1]), op, nil)
+	}).OnCancel(func() error {
+		return mainView.SetCurrent()
+	}).Show()
+}
@@ -0,0 +1,149 @@
+//Package orders keeps a local index from order IDs to the purchases they belong to, built from the purchases
+//seen by other operations, because the API can't look purchases up by order ID
+package orders
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+//Kinds of purchases
+const (
+	Product      = "product"
+	Subscription = "subscription"
+)
+
+//Purchase is what is known about the purchase of an order. ProductID and Kind may be empty when the source only
+//had the token, e.g. a voided purchase.
+type Purchase struct {
+	OrderID   string    `json:"orderId"`
+	Kind      string    `json:"kind,omitempty"`
+	ProductID string    `json:"productId,omitempty"`
+	Token     string    `json:"token,omitempty"`
+	Source    string    `json:"source"`
+	Seen      time.Time `json:"seen"`
+}
+
+//Index maps order IDs to purchases and is kept in a JSON file
+type Index struct {
+	mu        sync.Mutex
+	path      string
+	purchases map[string]Purchase
+}
+
+//Open loads the index at path, a missing file is an empty index
+func Open(path string) (*Index, error) {
+	idx := &Index{path: path, purchases: map[string]Purchase{}}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return idx, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &idx.purchases); err != nil {
+		return nil, errors.Wrapf(err, "unable to read order index (%v)", path)
+	}
+	return idx, nil
+}
+
+//Add records the purchase of an order, keeping what was known before if p lacks it.
+//It reports whether anything changed.
+func (idx *Index) Add(p Purchase) bool {
+	if p.OrderID == "" {
+		return false
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	old, ok := idx.purchases[p.OrderID]
+	if ok {
+		if p.Kind == "" {
+			p.Kind = old.Kind
+		}
+		if p.ProductID == "" {
+			p.ProductID = old.ProductID
+		}
+		if p.Token == "" {
+			p.Token = old.Token
+		}
+		if p.Kind == old.Kind && p.ProductID == old.ProductID && p.Token == old.Token {
+			return false
+		}
+	}
+	if p.Seen.IsZero() {
+		p.Seen = time.Now()
+	}
+	idx.purchases[p.OrderID] = p
+	return true
+}
+
+//BaseOrderID strips the renewal suffix of a subscription order ID, e.g. GPA.1234-5678-9012-34567..2
+func BaseOrderID(id string) string {
+	if i := strings.Index(id, ".."); i >= 0 {
+		return id[:i]
+	}
+	return id
+}
+
+//Lookup returns the purchases of an order and, for subscriptions, of its renewals
+func (idx *Index) Lookup(orderID string) []Purchase {
+	orderID = strings.TrimSpace(orderID)
+	base := BaseOrderID(orderID)
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	var found []Purchase
+	for id, p := range idx.purchases {
+		if id == orderID || BaseOrderID(id) == base {
+			found = append(found, p)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].OrderID < found[j].OrderID })
+	return found
+}
+
+//FindToken returns a purchase with the given token
+func (idx *Index) FindToken(token string) (Purchase, bool) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	for _, p := range idx.purchases {
+		if p.Token == token && p.ProductID != "" {
+			return p, true
+		}
+	}
+	return Purchase{}, false
+}
+
+//Save writes the index, replacing the file only once it's completely written
+func (idx *Index) Save() error {
+	idx.mu.Lock()
+	data, err := json.Marshal(idx.purchases)
+	idx.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(idx.path), 0700); err != nil {
+		return err
+	}
+	//every save writes a file of its own, concurrent saves can't rename each other's partly written files
+	f, err := ioutil.TempFile(filepath.Dir(idx.path), filepath.Base(idx.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), idx.path)
+}
@@ -0,0 +1,81 @@
+package orders
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestIndex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orders.json")
+	idx, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !idx.Add(Purchase{OrderID: "GPA.1111-2222-3333-44444", Token: "t1", Source: "voided purchases"}) {
+		t.Error("adding a new order should change the index")
+	}
+	if !idx.Add(Purchase{OrderID: "GPA.1111-2222-3333-44444", Kind: Subscription, ProductID: "monthly", Source: "Purchases.subscriptions.Get"}) {
+		t.Error("adding the product id should change the index")
+	}
+	if idx.Add(Purchase{OrderID: "GPA.1111-2222-3333-44444", Token: "t1", Source: "again"}) {
+		t.Error("adding nothing new shouldn't change the index")
+	}
+	idx.Add(Purchase{OrderID: "GPA.1111-2222-3333-44444..1", Kind: Subscription, ProductID: "monthly", Token: "t1"})
+	idx.Add(Purchase{OrderID: "GPA.9999-2222-3333-44444", Kind: Product, ProductID: "coins", Token: "t2"})
+	if err := idx.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := idx.Lookup(" GPA.1111-2222-3333-44444..1 ")
+	if len(found) != 2 {
+		t.Fatalf("Lookup() = %v", found)
+	}
+	if p := found[0]; p.Kind != Subscription || p.ProductID != "monthly" || p.Token != "t1" {
+		t.Errorf("unexpected purchase %+v", p)
+	}
+	if p, ok := idx.FindToken("t2"); !ok || p.ProductID != "coins" {
+		t.Errorf("FindToken() = %v, %v", p, ok)
+	}
+	if found := idx.Lookup("GPA.0000-0000-0000-00000"); len(found) != 0 {
+		t.Errorf("Lookup(unknown) = %v", found)
+	}
+}
+
+func TestConcurrentSaves(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "orders.json")
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		idx, err := Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		idx.Add(Purchase{OrderID: fmt.Sprintf("GPA.%v", i), Token: strings.Repeat("t", 10000)})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := idx.Save(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if _, err := Open(path); err != nil {
+		t.Errorf("torn file after concurrent saves: %v", err)
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("temporary files left behind: %v", len(files))
+	}
+}
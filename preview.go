@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/hassansin/androidpublisher/ui"
+	"github.com/hassansin/gocui"
+	"github.com/pkg/errors"
+)
+
+//errNotConfirmed is returned by operations with a preview when Do runs without a confirmed one
+var errNotConfirmed = errors.New("the changes have to be previewed and confirmed first")
+
+//Preview lists the items an operation is about to change
+type Preview struct {
+	Action  string      `json:"action"`
+	Count   int         `json:"count"`
+	Skipped int         `json:"skippedAsDone,omitempty"`
+	Items   interface{} `json:"items"`
+	//Plan is exactly what Do changes once the preview is confirmed, Count has to match it
+	Plan interface{} `json:"-"`
+}
+
+type planKey struct{}
+
+//confirmedPlan returns the plan of the preview confirmed for the running operation, nil if there's none
+func confirmedPlan(ctx context.Context) interface{} {
+	return ctx.Value(planKey{})
+}
+
+//confirmedOperation returns op with plan in the context of Do, so that it changes what was confirmed even if
+//its input has changed since the preview
+func confirmedOperation(op *Operation, plan interface{}) *Operation {
+	c := *op
+	c.Do = func(ctx context.Context, params []*Param) (interface{}, error) {
+		return op.Do(context.WithValue(ctx, planKey{}, plan), params)
+	}
+	c.previewed = op
+	return &c
+}
+
+//previewOperation returns an operation that runs the preview of op, op runs once the preview is confirmed
+func previewOperation(op *Operation) *Operation {
+	return &Operation{
+		Name:    op.Name + " (preview)",
+		Params:  op.Params,
+		Timeout: op.Timeout,
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
+			return op.Preview(ctx, params)
+		},
+		confirms: op,
+	}
+}
+
+//startOp runs op with its current parameters, or its preview if it has one
+func startOp(grp *Group, op *Operation, tab *ui.Tab) Job {
+	if op.Preview != nil {
+		op = previewOperation(op)
+	}
+	return jobs.Start(grp, op, tab)
+}
+
+//confirm asks to type the number of affected items before running the operation previewed by job
+func confirm(g *gocui.Gui, job Job) error {
+	preview := job.Result.(*Preview)
+	if preview.Count == 0 {
+		status.UpdateSuccess(fmt.Sprintf("#%v Nothing to %v", job.ID, preview.Action))
+		return nil
+	}
+	var answer string
+	maxX, maxY := g.Size()
+	f, err := ui.NewForm(g, fmt.Sprintf("%v %v items?", preview.Action, preview.Count), maxX/2-30, maxY/2-3)
+	if err != nil {
+		return err
+	}
+	f.OnCancel(func() error {
+		status.UpdateError(fmt.Sprintf("#%v Cancelled, nothing was changed", job.ID))
+		return sideView.SetCurrent()
+	}).OnError(func(err error) {
+		status.UpdateError(err.Error())
+	}).OnSubmit(func() error {
+		if answer != strconv.Itoa(preview.Count) {
+			status.UpdateError(fmt.Sprintf("#%v Confirmation didn't match, nothing was changed", job.ID))
+			return sideView.SetCurrent()
+		}
+		jobs.StartWith(job.Group, confirmedOperation(job.Op.confirms, preview.Plan), job.Params, job.tab)
+		return sideView.SetCurrent()
+	})
+	input := ui.NewInput(fmt.Sprintf("Type %v to confirm", preview.Count), &answer, 60, true)
+	input.Required = true
+	return f.Input(input)
+}
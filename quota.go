@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/hassansin/androidpublisher/transport"
+	"github.com/hassansin/androidpublisher/ui"
+	"github.com/hassansin/gocui"
+	"github.com/logrusorgru/aurora"
+	"github.com/spf13/viper"
+)
+
+var (
+	//budgetWarned is the last day a warning about the daily budget was shown
+	budgetWarned string
+	budgetMu     sync.Mutex
+)
+
+//dataDir returns the directory where local state like the quota usage is kept
+func dataDir() string {
+	if dir := viper.GetString("data-dir"); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".androidpublisher"
+	}
+	return filepath.Join(home, ".androidpublisher")
+}
+
+//quotaChanged shows the number of calls made today and warns when the daily budget is nearly used up
+func quotaChanged(usage transport.Usage) {
+	total := usage.Total()
+	budget := viper.GetInt("daily-budget")
+	if budget <= 0 {
+		status.SetRight(fmt.Sprintf("%v calls today", total))
+		return
+	}
+	msg := fmt.Sprintf("%v/%v calls today", total, budget)
+	if float64(total) < viper.GetFloat64("budget-warning")*float64(budget) {
+		status.SetRight(msg)
+		return
+	}
+	status.SetRight(aurora.Red(msg).String())
+	budgetMu.Lock()
+	defer budgetMu.Unlock()
+	if budgetWarned != usage.Day {
+		budgetWarned = usage.Day
+		status.UpdateError(fmt.Sprintf("%v%% of the daily budget of %v calls is used up", total*100/budget, budget))
+	}
+}
+
+//showQuota opens a tab with today's calls per API family
+func showQuota(g *gocui.Gui, v *gocui.View) error {
+	usage := quota.Usage()
+	var b strings.Builder
+	fmt.Fprintf(&b, "%v (Pacific Time)\n\n", aurora.Bold(fmt.Sprintf("API calls on %v", usage.Day)))
+	for _, family := range usage.Families() {
+		fmt.Fprintf(&b, "%-28v %8v\n", family, usage.Counts[family])
+	}
+	fmt.Fprintf(&b, "%-28v %8v\n", aurora.Bold("total"), usage.Total())
+	if budget := viper.GetInt("daily-budget"); budget > 0 {
+		fmt.Fprintf(&b, "%-28v %8v\n", "budget", budget)
+	}
+	mainView.Load(mainView.NewTab(), "Quota usage", ui.Text(b.String()))
+	return mainView.SetCurrent()
+}
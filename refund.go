@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hassansin/androidpublisher/apierror"
+	"github.com/hassansin/androidpublisher/bulk"
+	"github.com/pkg/errors"
+	androidpublisher "google.golang.org/api/androidpublisher/v3"
+)
+
+//refundParams are the parameters of the bulk refund operations
+func refundParams() []*Param {
+	return []*Param{{Name: "Input(csv)", Required: true}, {Name: "Report(csv)"}, {Name: "Revoke(true/false)"}, {Name: "Rate(per second)"}}
+}
+
+//refundJob is a bulk refund read from the parameters of an operation
+type refundJob struct {
+	input  string
+	table  *bulk.Table
+	keys   []int
+	report string
+	revoke bool
+	rate   float64
+}
+
+func newRefundJob(params []*Param, keys ...[]string) (*refundJob, error) {
+	table, err := bulk.ReadCSV(params[0].Value)
+	if err != nil {
+		return nil, err
+	}
+	job := &refundJob{
+		input:  params[0].Value,
+		table:  table,
+		report: params[1].Value,
+		revoke: strings.ToLower(params[2].Value) == "true",
+		rate:   2,
+	}
+	if job.report == "" {
+		job.report = strings.TrimSuffix(job.input, ".csv") + "-report.csv"
+	}
+	if params[3].Value != "" {
+		if job.rate, err = strconv.ParseFloat(params[3].Value, 64); err != nil || job.rate <= 0 {
+			return nil, errors.Errorf("invalid rate %q", params[3].Value)
+		}
+	}
+	for _, names := range keys {
+		col := table.Column(names...)
+		if col < 0 {
+			return nil, errors.Errorf("%v has no %v column", job.input, names[0])
+		}
+		job.keys = append(job.keys, col)
+	}
+	return job, nil
+}
+
+func (j *refundJob) action() string {
+	if j.revoke {
+		return "refund and revoke"
+	}
+	return "refund"
+}
+
+//key returns the values of the key columns of the i-th row
+func (j *refundJob) key(i int) []string {
+	key := make([]string, len(j.keys))
+	for k, col := range j.keys {
+		key[k] = j.table.Value(i, col)
+	}
+	return key
+}
+
+//reportKeys is the number of report columns identifying an item: the key columns and the action, so that a report
+//of refunds doesn't count as done when revoking
+func (j *refundJob) reportKeys() int {
+	return len(j.keys) + 1
+}
+
+//done reports whether the report has the item with key done with the action of the job
+func (j *refundJob) done(report *bulk.Report, key []string) bool {
+	return report.Done(append(key[:len(key):len(key)], j.action())...)
+}
+
+//pending returns the rows that aren't empty, duplicated or already done according to the report, which is only
+//read
+func (j *refundJob) pending(header []string) (rows []int, skipped int, err error) {
+	report, err := bulk.ReadReport(j.report, header, j.reportKeys())
+	if err != nil {
+		return nil, 0, err
+	}
+	defer report.Close()
+	seen := map[string]bool{}
+	for i := range j.table.Rows {
+		key := j.key(i)
+		id := strings.Join(key, "\x00")
+		if strings.Join(key, "") == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		if j.done(report, key) {
+			skipped++
+			continue
+		}
+		rows = append(rows, i)
+	}
+	return rows, skipped, nil
+}
+
+//refundPlan is a confirmed bulk refund: the job and the keys of the rows to refund
+type refundPlan struct {
+	job  *refundJob
+	keys [][]string
+}
+
+//plan returns the pending rows as a plan
+func (j *refundJob) plan(header []string) (*refundPlan, int, error) {
+	rows, skipped, err := j.pending(header)
+	if err != nil {
+		return nil, 0, err
+	}
+	p := &refundPlan{job: j}
+	for _, row := range rows {
+		p.keys = append(p.keys, j.key(row))
+	}
+	return p, skipped, nil
+}
+
+//run calls fn for every key of the plan at the configured rate and writes the outcome to the report. Keys that
+//the report has as done by now are skipped, the plan is never extended.
+func (p *refundPlan) run(ctx context.Context, header []string, fn func(ctx context.Context, key []string) error) (interface{}, error) {
+	j := p.job
+	report, err := bulk.OpenReport(j.report, header, j.reportKeys())
+	if err != nil {
+		return nil, err
+	}
+	defer report.Close()
+
+	var keys [][]string
+	for _, key := range p.keys {
+		if !j.done(report, key) {
+			keys = append(keys, key)
+		}
+	}
+	result := BulkResult{Input: j.input, Output: j.report, Rows: len(keys), Skipped: len(p.keys) - len(keys)}
+	var mu sync.Mutex
+	runErr := bulk.Run(ctx, len(keys), 4, bulk.Limit(j.rate, func(ctx context.Context, i int) error {
+		key := keys[i]
+		err := fn(ctx, key)
+		if err != nil && ctx.Err() != nil {
+			//leave interrupted items out of the report so that they're retried when resuming
+			return err
+		}
+		msg := ""
+		if err != nil {
+			msg = apierror.Summary(err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			result.Failed++
+		} else {
+			result.Succeeded++
+		}
+		if werr := report.Write(append(key, j.action()), msg); werr != nil {
+			return werr
+		}
+		return err
+	}), func(done, failed, total int) {
+		reportProgress(ctx, Progress{Done: done, Failed: failed, Total: total})
+	})
+	if runErr != nil {
+		return nil, runErr
+	}
+	return result, nil
+}
+
+//refundOrdersOperation refunds, and optionally revokes, every order listed in the orderId column of a CSV file
+func refundOrdersOperation(service *androidpublisher.Service, pkgName string) *Operation {
+	header := []string{"orderId", "action"}
+	return &Operation{
+		Name:    "BulkRefund",
+		Params:  refundParams(),
+		Timeout: bulkTimeout,
+		Preview: func(ctx context.Context, params []*Param) (*Preview, error) {
+			job, err := newRefundJob(params, []string{"orderId", "order"})
+			if err != nil {
+				return nil, err
+			}
+			plan, skipped, err := job.plan(header)
+			if err != nil {
+				return nil, err
+			}
+			orders := make([]string, len(plan.keys))
+			for i, key := range plan.keys {
+				orders[i] = key[0]
+			}
+			return &Preview{Action: job.action(), Count: len(plan.keys), Skipped: skipped, Items: orders, Plan: plan}, nil
+		},
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
+			plan, ok := confirmedPlan(ctx).(*refundPlan)
+			if !ok {
+				return nil, errNotConfirmed
+			}
+			s := androidpublisher.NewOrdersService(service)
+			return plan.run(ctx, header, func(ctx context.Context, key []string) error {
+				return s.Refund(pkgName, key[0]).Revoke(plan.job.revoke).Context(ctx).Do()
+			})
+		},
+	}
+}
+
+//subscriptionPreview is a subscription about to be refunded
+type subscriptionPreview struct {
+	SubscriptionID string `json:"subscriptionId"`
+	Token          string `json:"token"`
+	OrderID        string `json:"orderId,omitempty"`
+	ExpiryTime     string `json:"expiryTime,omitempty"`
+	AutoRenewing   bool   `json:"autoRenewing"`
+	Error          string `json:"error,omitempty"`
+}
+
+//refundSubscriptionsOperation refunds every subscription listed in the subscriptionId and token columns of a CSV file.
+//Revoking also terminates the subscriptions immediately.
+func refundSubscriptionsOperation(service *androidpublisher.Service, pkgName string) *Operation {
+	header := []string{"subscriptionId", "token", "action"}
+	keys := [][]string{{"subscriptionId", "sku", "productId"}, {"token", "purchaseToken"}}
+	return &Operation{
+		Name:    "BulkRefund",
+		Params:  refundParams(),
+		Timeout: bulkTimeout,
+		Preview: func(ctx context.Context, params []*Param) (*Preview, error) {
+			job, err := newRefundJob(params, keys...)
+			if err != nil {
+				return nil, err
+			}
+			pending, skipped, err := job.plan(header)
+			if err != nil {
+				return nil, err
+			}
+			s := androidpublisher.NewPurchasesSubscriptionsService(service)
+			subs := make([]subscriptionPreview, len(pending.keys))
+			err = bulk.Run(ctx, len(pending.keys), 4, func(ctx context.Context, i int) error {
+				key := pending.keys[i]
+				subs[i] = subscriptionPreview{SubscriptionID: key[0], Token: key[1]}
+				sub, err := s.Get(pkgName, key[0], key[1]).Context(ctx).Do()
+				if err != nil {
+					subs[i].Error = apierror.Summary(err)
+					return err
+				}
+				subs[i].OrderID = sub.OrderId
+				subs[i].ExpiryTime = formatMillis(sub.ExpiryTimeMillis)
+				subs[i].AutoRenewing = sub.AutoRenewing
+				return nil
+			}, func(done, failed, total int) {
+				reportProgress(ctx, Progress{Done: done, Failed: failed, Total: total})
+			})
+			if err != nil {
+				return nil, err
+			}
+			//subscriptions that couldn't be looked up are listed with their error, but not refunded
+			plan := &refundPlan{job: job}
+			for i, sub := range subs {
+				if sub.Error == "" {
+					plan.keys = append(plan.keys, pending.keys[i])
+				}
+			}
+			return &Preview{Action: job.action(), Count: len(plan.keys), Skipped: skipped, Items: subs, Plan: plan}, nil
+		},
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
+			plan, ok := confirmedPlan(ctx).(*refundPlan)
+			if !ok {
+				return nil, errNotConfirmed
+			}
+			s := androidpublisher.NewPurchasesSubscriptionsService(service)
+			return plan.run(ctx, header, func(ctx context.Context, key []string) error {
+				if plan.job.revoke {
+					return s.Revoke(pkgName, key[0], key[1]).Context(ctx).Do()
+				}
+				return s.Refund(pkgName, key[0], key[1]).Context(ctx).Do()
+			})
+		},
+	}
+}
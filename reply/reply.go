@@ -0,0 +1,74 @@
+//Package reply fills review reply templates
+package reply
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	androidpublisher "google.golang.org/api/androidpublisher/v3"
+)
+
+//MaxLength is the maximum number of characters of a reply
+const MaxLength = 350
+
+//Template is a reply with placeholders such as {author}, see Vars
+type Template struct {
+	Name string `json:"name"`
+	Text string `json:"text"`
+}
+
+//LoadTemplates reads a JSON array of templates, a missing file means there are no templates
+func LoadTemplates(path string) ([]Template, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var templates []Template
+	if err := json.Unmarshal(data, &templates); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse reply templates (%v)", path)
+	}
+	return templates, nil
+}
+
+//Vars returns the placeholder values of a review: {author}, {rating}, {version}, {device}, {language} and {text}
+func Vars(review *androidpublisher.Review) map[string]string {
+	vars := map[string]string{"author": review.AuthorName}
+	for _, c := range review.Comments {
+		u := c.UserComment
+		if u == nil {
+			continue
+		}
+		vars["rating"] = strconv.FormatInt(u.StarRating, 10)
+		vars["version"] = u.AppVersionName
+		vars["language"] = u.ReviewerLanguage
+		vars["text"] = strings.TrimSpace(u.Text)
+		vars["device"] = u.Device
+		if u.DeviceMetadata != nil && u.DeviceMetadata.ProductName != "" {
+			vars["device"] = u.DeviceMetadata.ProductName
+		}
+		break
+	}
+	return vars
+}
+
+//Fill replaces the placeholders of text with vars, unknown placeholders are kept
+func Fill(text string, vars map[string]string) string {
+	var pairs []string
+	for k, v := range vars {
+		pairs = append(pairs, "{"+k+"}", v)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+//Length returns the number of characters of a reply as counted against MaxLength
+func Length(text string) int {
+	return utf8.RuneCountInString(text)
+}
@@ -0,0 +1,42 @@
+package reply
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	androidpublisher "google.golang.org/api/androidpublisher/v3"
+)
+
+func TestFill(t *testing.T) {
+	review := &androidpublisher.Review{
+		AuthorName: "Alex",
+		Comments: []*androidpublisher.Comment{{UserComment: &androidpublisher.UserComment{
+			StarRating:     2,
+			AppVersionName: "1.4.0",
+			Device:         "walleye",
+			DeviceMetadata: &androidpublisher.DeviceMetadata{ProductName: "Pixel 2"},
+		}}},
+	}
+	got := Fill("Hi {author}, sorry about {version} on your {device} ({rating}★). {unknown}", Vars(review))
+	want := "Hi Alex, sorry about 1.4.0 on your Pixel 2 (2★). {unknown}"
+	if got != want {
+		t.Errorf("Fill() = %q, want %q", got, want)
+	}
+	if Length("héllo") != 5 {
+		t.Error("Length should count characters")
+	}
+}
+
+func TestLoadTemplates(t *testing.T) {
+	dir := t.TempDir()
+	if templates, err := LoadTemplates(filepath.Join(dir, "missing.json")); err != nil || templates != nil {
+		t.Errorf("LoadTemplates(missing) = %v, %v", templates, err)
+	}
+	path := filepath.Join(dir, "templates.json")
+	ioutil.WriteFile(path, []byte(`[{"name": "thanks", "text": "Thanks {author}!"}]`), 0644)
+	templates, err := LoadTemplates(path)
+	if err != nil || len(templates) != 1 || templates[0].Name != "thanks" {
+		t.Errorf("LoadTemplates() = %v, %v", templates, err)
+	}
+}
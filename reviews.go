@@ -0,0 +1,278 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hassansin/androidpublisher/reply"
+	"github.com/hassansin/androidpublisher/reviews"
+	"github.com/hassansin/androidpublisher/ui"
+	"github.com/hassansin/gocui"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	androidpublisher "google.golang.org/api/androidpublisher/v3"
+)
+
+//replyTemplates returns the path of the reply templates file
+func replyTemplates() string {
+	if path := viper.GetString("reply-templates"); path != "" {
+		return path
+	}
+	return filepath.Join(dataDir(), "reply-templates.json")
+}
+
+//tabReviews returns the reviews loaded into the current tab by Reviews.List or Reviews.Get
+func tabReviews() []*androidpublisher.Review {
+	switch body := mainView.CurrentTab().Body().(type) {
+	case *androidpublisher.ReviewsListResponse:
+		return body.Reviews
+	case *androidpublisher.Review:
+		return []*androidpublisher.Review{body}
+	}
+	return nil
+}
+
+func reviewItem(review *androidpublisher.Review) string {
+	vars := reply.Vars(review)
+	text := strings.Join(strings.Fields(vars["text"]), " ")
+	if len([]rune(text)) > 50 {
+		text = string([]rune(text)[:49]) + "…"
+	}
+	return fmt.Sprintf("%v★ %-20v %v", vars["rating"], vars["author"], text)
+}
+
+//replyToReview picks one of the reviews of the current tab and a template to reply with
+func replyToReview(g *gocui.Gui, v *gocui.View) error {
+	reviews := tabReviews()
+	switch len(reviews) {
+	case 0:
+		status.UpdateError("No reviews in this tab, load them with Reviews.List or Reviews.Get")
+		return nil
+	case 1:
+		return pickTemplate(g, reviews[0])
+	}
+	items := make([]string, len(reviews))
+	for i, review := range reviews {
+		items[i] = reviewItem(review)
+	}
+	return ui.NewList(g, "Reply to", items).OnSelect(func(idx int) error {
+		return pickTemplate(g, reviews[idx])
+	}).OnCancel(func() error {
+		return mainView.SetCurrent()
+	}).Show()
+}
+
+//pickTemplate fills the reply form with one of the templates, if there are any
+func pickTemplate(g *gocui.Gui, review *androidpublisher.Review) error {
+	templates, err := reply.LoadTemplates(replyTemplates())
+	if err != nil {
+		status.UpdateError(err.Error())
+		return nil
+	}
+	if len(templates) == 0 {
+		return replyForm(g, review, "")
+	}
+	items := []string{"(blank)"}
+	for _, t := range templates {
+		items = append(items, t.Name)
+	}
+	return ui.NewList(g, "Template", items).OnSelect(func(idx int) error {
+		if idx == 0 {
+			return replyForm(g, review, "")
+		}
+		return replyForm(g, review, templates[idx-1].Text)
+	}).OnCancel(func() error {
+		return mainView.SetCurrent()
+	}).Show()
+}
+
+//replyForm opens the Reviews.Reply form for review with the template filled in
+func replyForm(g *gocui.Gui, review *androidpublisher.Review, template string) error {
+	op := groups.Find("Reviews.Reply")
+	op.Params[0].Value = review.ReviewId
+	op.Params[1].Value = reply.Fill(template, reply.Vars(review))
+	return showParams(g, groups.Group("Reviews"), op, nil)
+}
+
+//reviewsCacheTTL is how long the reviews fetched by the dashboard are reused
+const reviewsCacheTTL = 5 * time.Minute
+
+//reviewsCache keeps the reviews fetched by the dashboard, so that it can be filtered and sorted again quickly
+var reviewsCache struct {
+	sync.Mutex
+	reviews []*androidpublisher.Review
+	fetched time.Time
+}
+
+//listReviews returns every review, following the page tokens
+func listReviews(ctx context.Context, s *androidpublisher.ReviewsService, pkgName string) ([]*androidpublisher.Review, error) {
+	var all []*androidpublisher.Review
+	token := ""
+	for {
+		call := s.List(pkgName).MaxResults(100)
+		if token != "" {
+			call.Token(token)
+		}
+		res, err := call.Context(ctx).Do()
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, res.Reviews...)
+		if res.TokenPagination == nil || res.TokenPagination.NextPageToken == "" {
+			return all, nil
+		}
+		token = res.TokenPagination.NextPageToken
+	}
+}
+
+//dashboardParams are the filter, sort and statistics parameters shared by the dashboard and the archive
+func dashboardParams() []*Param {
+	return []*Param{
+		{Name: "Rating(e.g. 1-2)"}, {Name: "VersionCode"}, {Name: "Keyword"},
+		{Name: "SortBy(rating/language/version/device/date/replied, -desc)"}, {Name: "UnansweredDays"},
+	}
+}
+
+//dashboard renders the reviews selected by the dashboard parameters as a table with aggregate counts
+func dashboard(all []*androidpublisher.Review, source string, params []*Param) (interface{}, error) {
+	var (
+		filter reviews.Filter
+		err    error
+		days   = 7
+	)
+	if filter.MinRating, filter.MaxRating, err = reviews.ParseRating(params[0].Value); err != nil {
+		return nil, err
+	}
+	if params[1].Value != "" {
+		if filter.VersionCode, err = strconv.ParseInt(params[1].Value, 10, 64); err != nil {
+			return nil, errors.Errorf("invalid version code %q", params[1].Value)
+		}
+	}
+	filter.Keyword = params[2].Value
+	if params[4].Value != "" {
+		if days, err = strconv.Atoi(params[4].Value); err != nil {
+			return nil, errors.Errorf("invalid number of days %q", params[4].Value)
+		}
+	}
+	rows := filter.Apply(reviews.Rows(all))
+	if err := reviews.Sort(rows, params[3].Value); err != nil {
+		return nil, err
+	}
+	stats := reviews.Summarize(rows, time.Now(), days)
+	return ui.Text(fmt.Sprintf("%v of %v reviews, %v\n\n%v\n%v", len(rows), len(all), source, stats.Report(days), reviews.Table(rows, 80))), nil
+}
+
+//dashboardOperation fetches all reviews and shows them as a table with aggregate counts.
+//The fetched reviews are added to the archive.
+func dashboardOperation(service *androidpublisher.Service, pkgName string) *Operation {
+	return &Operation{
+		Name:   "Dashboard",
+		Params: append(dashboardParams(), &Param{Name: "Refresh(true/false)"}),
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
+			reviewsCache.Lock()
+			defer reviewsCache.Unlock()
+			if strings.ToLower(params[5].Value) == "true" || time.Since(reviewsCache.fetched) > reviewsCacheTTL {
+				all, err := listReviews(ctx, androidpublisher.NewReviewsService(service), pkgName)
+				if err != nil {
+					return nil, err
+				}
+				if _, _, err := archiveReviews(pkgName, all); err != nil {
+					return nil, err
+				}
+				reviewsCache.reviews, reviewsCache.fetched = all, time.Now()
+			}
+			return dashboard(reviewsCache.reviews, "fetched "+reviewsCache.fetched.Format("15:04:05"), params)
+		},
+	}
+}
+
+//reviewArchive is the archive of the reviews of the package, it's opened when first used
+var reviewArchive struct {
+	sync.Mutex
+	archive *reviews.Archive
+}
+
+func archivePath(pkgName string) string {
+	return filepath.Join(dataDir(), "reviews-"+pkgName+".json")
+}
+
+//openArchive returns the review archive of the package
+func openArchive(pkgName string) (*reviews.Archive, error) {
+	reviewArchive.Lock()
+	defer reviewArchive.Unlock()
+	if reviewArchive.archive == nil {
+		archive, err := reviews.OpenArchive(archivePath(pkgName))
+		if err != nil {
+			return nil, err
+		}
+		reviewArchive.archive = archive
+	}
+	return reviewArchive.archive, nil
+}
+
+//archiveReviews adds the reviews to the archive and saves it
+func archiveReviews(pkgName string, all []*androidpublisher.Review) (added, updated int, err error) {
+	archive, err := openArchive(pkgName)
+	if err != nil {
+		return 0, 0, err
+	}
+	added, updated = archive.Add(all)
+	if added+updated > 0 {
+		err = errors.Wrap(archive.Save(), "unable to save review archive")
+	}
+	return added, updated, err
+}
+
+//SyncResult summarizes a sync of the review archive
+type SyncResult struct {
+	Fetched  int    `json:"fetched"`
+	Added    int    `json:"added"`
+	Updated  int    `json:"updated"`
+	Archived int    `json:"archived"`
+	File     string `json:"file"`
+}
+
+//syncOperation fetches every review and adds the new ones, and new versions of known ones, to the archive
+func syncOperation(service *androidpublisher.Service, pkgName string) *Operation {
+	return &Operation{
+		Name: "Sync",
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
+			all, err := listReviews(ctx, androidpublisher.NewReviewsService(service), pkgName)
+			if err != nil {
+				return nil, err
+			}
+			added, updated, err := archiveReviews(pkgName, all)
+			if err != nil {
+				return nil, err
+			}
+			archive, _ := openArchive(pkgName)
+			return SyncResult{
+				Fetched:  len(all),
+				Added:    added,
+				Updated:  updated,
+				Archived: archive.Len(),
+				File:     archivePath(pkgName),
+			}, nil
+		},
+	}
+}
+
+//archiveOperation browses and searches the archived reviews without any API calls
+func archiveOperation(pkgName string) *Operation {
+	return &Operation{
+		Name:   "Archive",
+		Params: dashboardParams(),
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
+			archive, err := openArchive(pkgName)
+			if err != nil {
+				return nil, err
+			}
+			return dashboard(archive.Latest(), "from the archive", params)
+		},
+	}
+}
@@ -0,0 +1,148 @@
+package reviews
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	androidpublisher "google.golang.org/api/androidpublisher/v3"
+)
+
+//Archive keeps every version of the reviews it's given in a JSON file, so that they outlive the week for which
+//the API returns them. A version is identified by the review ID and the time it was last modified.
+type Archive struct {
+	mu   sync.Mutex
+	path string
+	//versions of each review by ID, oldest first
+	versions map[string][]*androidpublisher.Review
+}
+
+//OpenArchive loads the archive at path, a missing file is an empty archive
+func OpenArchive(path string) (*Archive, error) {
+	a := &Archive{path: path, versions: map[string][]*androidpublisher.Review{}}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return a, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &a.versions); err != nil {
+		return nil, errors.Wrapf(err, "unable to read review archive (%v)", path)
+	}
+	return a, nil
+}
+
+//Modified returns when the review or its reply was last modified
+func Modified(review *androidpublisher.Review) int64 {
+	var modified int64
+	for _, c := range review.Comments {
+		for _, t := range []*androidpublisher.Timestamp{userModified(c), developerModified(c)} {
+			if t != nil && t.Seconds*1e9+t.Nanos > modified {
+				modified = t.Seconds*1e9 + t.Nanos
+			}
+		}
+	}
+	return modified
+}
+
+func userModified(c *androidpublisher.Comment) *androidpublisher.Timestamp {
+	if c.UserComment == nil {
+		return nil
+	}
+	return c.UserComment.LastModified
+}
+
+func developerModified(c *androidpublisher.Comment) *androidpublisher.Timestamp {
+	if c.DeveloperComment == nil {
+		return nil
+	}
+	return c.DeveloperComment.LastModified
+}
+
+//Add stores the reviews that aren't archived yet. It returns the number of new reviews and of new versions of
+//archived ones.
+func (a *Archive) Add(reviews []*androidpublisher.Review) (added, updated int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, review := range reviews {
+		versions := a.versions[review.ReviewId]
+		modified := Modified(review)
+		known := false
+		for _, v := range versions {
+			if Modified(v) == modified {
+				known = true
+				break
+			}
+		}
+		if known {
+			continue
+		}
+		if len(versions) == 0 {
+			added++
+		} else {
+			updated++
+		}
+		versions = append(versions, review)
+		sort.SliceStable(versions, func(i, j int) bool { return Modified(versions[i]) < Modified(versions[j]) })
+		a.versions[review.ReviewId] = versions
+	}
+	return added, updated
+}
+
+//Latest returns the latest version of every archived review
+func (a *Archive) Latest() []*androidpublisher.Review {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	latest := make([]*androidpublisher.Review, 0, len(a.versions))
+	for _, versions := range a.versions {
+		latest = append(latest, versions[len(versions)-1])
+	}
+	sort.Slice(latest, func(i, j int) bool { return latest[i].ReviewId < latest[j].ReviewId })
+	return latest
+}
+
+//Versions returns every archived version of a review, oldest first
+func (a *Archive) Versions(id string) []*androidpublisher.Review {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]*androidpublisher.Review(nil), a.versions[id]...)
+}
+
+//Len returns the number of archived reviews
+func (a *Archive) Len() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.versions)
+}
+
+//Save writes the archive, replacing the file only once it's completely written
+func (a *Archive) Save() error {
+	a.mu.Lock()
+	data, err := json.Marshal(a.versions)
+	a.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(a.path), 0700); err != nil {
+		return err
+	}
+	//every save writes a file of its own, concurrent saves can't rename each other's partly written files
+	f, err := ioutil.TempFile(filepath.Dir(a.path), filepath.Base(a.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), a.path)
+}
@@ -0,0 +1,245 @@
+//Package reviews flattens, filters, sorts and summarizes app reviews
+package reviews
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	androidpublisher "google.golang.org/api/androidpublisher/v3"
+)
+
+//Row is a review with the fields of its user and developer comments flattened
+type Row struct {
+	ID          string    `json:"reviewId"`
+	Author      string    `json:"author,omitempty"`
+	Rating      int64     `json:"rating"`
+	Language    string    `json:"language,omitempty"`
+	VersionCode int64     `json:"versionCode,omitempty"`
+	VersionName string    `json:"versionName,omitempty"`
+	Device      string    `json:"device,omitempty"`
+	Text        string    `json:"text"`
+	Date        time.Time `json:"date"`
+	Reply       string    `json:"reply,omitempty"`
+	ReplyDate   time.Time `json:"replyDate,omitempty"`
+}
+
+//Replied reports whether the developer replied to the review
+func (r Row) Replied() bool {
+	return r.Reply != ""
+}
+
+//NewRow flattens a review
+func NewRow(review *androidpublisher.Review) Row {
+	row := Row{ID: review.ReviewId, Author: review.AuthorName}
+	for _, c := range review.Comments {
+		if u := c.UserComment; u != nil && row.Date.IsZero() {
+			row.Rating = u.StarRating
+			row.Language = u.ReviewerLanguage
+			row.VersionCode = u.AppVersionCode
+			row.VersionName = u.AppVersionName
+			row.Device = u.Device
+			if u.DeviceMetadata != nil && u.DeviceMetadata.ProductName != "" {
+				row.Device = u.DeviceMetadata.ProductName
+			}
+			row.Text = strings.TrimSpace(u.Text)
+			row.Date = timestamp(u.LastModified)
+		}
+		if d := c.DeveloperComment; d != nil {
+			row.Reply = d.Text
+			row.ReplyDate = timestamp(d.LastModified)
+		}
+	}
+	return row
+}
+
+//Rows flattens the reviews
+func Rows(reviews []*androidpublisher.Review) []Row {
+	rows := make([]Row, len(reviews))
+	for i, review := range reviews {
+		rows[i] = NewRow(review)
+	}
+	return rows
+}
+
+func timestamp(t *androidpublisher.Timestamp) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return time.Unix(t.Seconds, t.Nanos).UTC()
+}
+
+//Filter selects reviews by rating, app version and keyword. Zero values match every review.
+type Filter struct {
+	MinRating, MaxRating int64
+	VersionCode          int64
+	Keyword              string
+}
+
+//ParseRating parses a rating like "1" or a range like "1-3"
+func ParseRating(s string) (min, max int64, err error) {
+	if s == "" {
+		return 0, 0, nil
+	}
+	parts := strings.SplitN(s, "-", 2)
+	if min, err = strconv.ParseInt(strings.TrimSpace(parts[0]), 10, 64); err != nil {
+		return 0, 0, errors.Errorf("invalid rating %q", s)
+	}
+	max = min
+	if len(parts) == 2 {
+		if max, err = strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 64); err != nil {
+			return 0, 0, errors.Errorf("invalid rating %q", s)
+		}
+	}
+	if min < 1 || max > 5 || min > max {
+		return 0, 0, errors.Errorf("invalid rating %q, expected 1 to 5 stars", s)
+	}
+	return min, max, nil
+}
+
+//Match reports whether r is selected by the filter
+func (f Filter) Match(r Row) bool {
+	switch {
+	case f.MinRating > 0 && r.Rating < f.MinRating:
+		return false
+	case f.MaxRating > 0 && r.Rating > f.MaxRating:
+		return false
+	case f.VersionCode > 0 && r.VersionCode != f.VersionCode:
+		return false
+	case f.Keyword != "":
+		keyword := strings.ToLower(f.Keyword)
+		return strings.Contains(strings.ToLower(r.Text), keyword) || strings.Contains(strings.ToLower(r.Reply), keyword)
+	}
+	return true
+}
+
+//Apply returns the rows selected by the filter
+func (f Filter) Apply(rows []Row) []Row {
+	var selected []Row
+	for _, r := range rows {
+		if f.Match(r) {
+			selected = append(selected, r)
+		}
+	}
+	return selected
+}
+
+//columns are the sortable columns of the table, by name
+var columns = map[string]func(a, b Row) bool{
+	"rating":   func(a, b Row) bool { return a.Rating < b.Rating },
+	"language": func(a, b Row) bool { return a.Language < b.Language },
+	"version":  func(a, b Row) bool { return a.VersionCode < b.VersionCode },
+	"device":   func(a, b Row) bool { return a.Device < b.Device },
+	"date":     func(a, b Row) bool { return a.Date.Before(b.Date) },
+	"replied":  func(a, b Row) bool { return !a.Replied() && b.Replied() },
+}
+
+//Sort sorts rows by a column: rating, language, version, device, date or replied.
+//A leading "-" sorts in descending order. Rows are sorted by date, newest first, if by is empty.
+func Sort(rows []Row, by string) error {
+	if by == "" {
+		by = "-date"
+	}
+	desc := strings.HasPrefix(by, "-")
+	less, ok := columns[strings.TrimPrefix(by, "-")]
+	if !ok {
+		return errors.Errorf("unknown column %q, expected rating, language, version, device, date or replied", by)
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if desc {
+			return less(rows[j], rows[i])
+		}
+		return less(rows[i], rows[j])
+	})
+	return nil
+}
+
+//VersionStats are the ratings of an app version
+type VersionStats struct {
+	VersionCode int64   `json:"versionCode"`
+	Reviews     int     `json:"reviews"`
+	Average     float64 `json:"averageRating"`
+}
+
+//Stats are aggregate counts of reviews
+type Stats struct {
+	Reviews    int            `json:"reviews"`
+	Average    float64        `json:"averageRating"`
+	Ratings    map[int64]int  `json:"ratings"`
+	Replied    int            `json:"replied"`
+	Unanswered int            `json:"unansweredOlderThanDays"`
+	Versions   []VersionStats `json:"versions"`
+}
+
+//Summarize computes the stats of rows, counting the unanswered reviews older than days
+func Summarize(rows []Row, now time.Time, days int) Stats {
+	stats := Stats{Reviews: len(rows), Ratings: map[int64]int{}}
+	versions := map[int64]*VersionStats{}
+	var total int64
+	cutoff := now.AddDate(0, 0, -days)
+	for _, r := range rows {
+		total += r.Rating
+		stats.Ratings[r.Rating]++
+		if r.Replied() {
+			stats.Replied++
+		} else if r.Date.Before(cutoff) {
+			stats.Unanswered++
+		}
+		v, ok := versions[r.VersionCode]
+		if !ok {
+			v = &VersionStats{VersionCode: r.VersionCode}
+			versions[r.VersionCode] = v
+		}
+		v.Reviews++
+		v.Average += float64(r.Rating)
+	}
+	if len(rows) > 0 {
+		stats.Average = float64(total) / float64(len(rows))
+	}
+	for _, v := range versions {
+		v.Average /= float64(v.Reviews)
+		stats.Versions = append(stats.Versions, *v)
+	}
+	sort.Slice(stats.Versions, func(i, j int) bool { return stats.Versions[i].VersionCode > stats.Versions[j].VersionCode })
+	return stats
+}
+
+//Table renders the rows as an aligned text table, review texts are cut to width characters
+func Table(rows []Row, width int) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RATING\tLANG\tVERSION\tDEVICE\tDATE\tREPLIED\tTEXT")
+	for _, r := range rows {
+		replied := "no"
+		if r.Replied() {
+			replied = "yes"
+		}
+		text := []rune(strings.Join(strings.Fields(r.Text), " "))
+		if len(text) > width {
+			text = append(text[:width-1], '…')
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\n", strings.Repeat("★", int(r.Rating)), r.Language, r.VersionCode, r.Device, r.Date.Format("2006-01-02"), replied, string(text))
+	}
+	w.Flush()
+	return b.String()
+}
+
+//Report renders the stats
+func (s Stats) Report(days int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%v reviews, average rating %.2f, %v replied, %v unanswered for more than %v days\n", s.Reviews, s.Average, s.Replied, s.Unanswered, days)
+	for rating := int64(5); rating >= 1; rating-- {
+		fmt.Fprintf(&b, "%v%v %v\n", strings.Repeat("★", int(rating)), strings.Repeat(" ", 5-int(rating)), s.Ratings[rating])
+	}
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\nVERSION\tREVIEWS\tAVERAGE")
+	for _, v := range s.Versions {
+		fmt.Fprintf(w, "%v\t%v\t%.2f\n", v.VersionCode, v.Reviews, v.Average)
+	}
+	w.Flush()
+	return b.String()
+}
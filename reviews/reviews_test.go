@@ -0,0 +1,145 @@
+package reviews
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	androidpublisher "google.golang.org/api/androidpublisher/v3"
+)
+
+var now = time.Date(2019, 5, 20, 0, 0, 0, 0, time.UTC)
+
+func review(id string, rating, version int64, daysAgo int, text, reply string) *androidpublisher.Review {
+	r := &androidpublisher.Review{
+		ReviewId: id,
+		Comments: []*androidpublisher.Comment{{UserComment: &androidpublisher.UserComment{
+			StarRating:     rating,
+			AppVersionCode: version,
+			Text:           text,
+			LastModified:   &androidpublisher.Timestamp{Seconds: now.AddDate(0, 0, -daysAgo).Unix()},
+		}}},
+	}
+	if reply != "" {
+		r.Comments = append(r.Comments, &androidpublisher.Comment{DeveloperComment: &androidpublisher.DeveloperComment{Text: reply}})
+	}
+	return r
+}
+
+func testRows() []Row {
+	return Rows([]*androidpublisher.Review{
+		review("a", 1, 10, 10, "Crashes on start", ""),
+		review("b", 5, 11, 1, "Love it", "Thanks!"),
+		review("c", 2, 11, 3, "crashes sometimes", ""),
+		review("d", 4, 10, 20, "Nice", "Thank you"),
+	})
+}
+
+func TestFilter(t *testing.T) {
+	min, max, err := ParseRating("1-2")
+	if err != nil || min != 1 || max != 2 {
+		t.Fatalf("ParseRating() = %v, %v, %v", min, max, err)
+	}
+	if _, _, err := ParseRating("0-6"); err == nil {
+		t.Error("expected error for invalid rating")
+	}
+	rows := Filter{MinRating: min, MaxRating: max, Keyword: "CRASH"}.Apply(testRows())
+	if len(rows) != 2 {
+		t.Errorf("filtered %v rows", len(rows))
+	}
+	if rows := (Filter{VersionCode: 11}).Apply(testRows()); len(rows) != 2 || rows[0].ID != "b" {
+		t.Errorf("unexpected rows %v", rows)
+	}
+}
+
+func TestSort(t *testing.T) {
+	rows := testRows()
+	if err := Sort(rows, ""); err != nil || rows[0].ID != "b" || rows[3].ID != "d" {
+		t.Errorf("default sort = %v, %v", rows, err)
+	}
+	if err := Sort(rows, "-rating"); err != nil || rows[0].ID != "b" || rows[3].ID != "a" {
+		t.Errorf("sort by rating = %v, %v", rows, err)
+	}
+	if err := Sort(rows, "stars"); err == nil {
+		t.Error("expected error for unknown column")
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	stats := Summarize(testRows(), now, 7)
+	if stats.Reviews != 4 || stats.Average != 3 || stats.Replied != 2 || stats.Unanswered != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if len(stats.Versions) != 2 || stats.Versions[0].VersionCode != 11 || stats.Versions[0].Average != 3.5 {
+		t.Errorf("unexpected version stats %+v", stats.Versions)
+	}
+	if report := stats.Report(7); !strings.Contains(report, "1 unanswered for more than 7 days") {
+		t.Errorf("unexpected report %v", report)
+	}
+}
+
+func TestArchive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reviews.json")
+	a, err := OpenArchive(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := review("a", 1, 10, 10, "Crashes on start", "")
+	if added, updated := a.Add([]*androidpublisher.Review{first, review("b", 5, 11, 1, "Love it", "")}); added != 2 || updated != 0 {
+		t.Errorf("Add() = %v, %v", added, updated)
+	}
+	if added, updated := a.Add([]*androidpublisher.Review{first}); added != 0 || updated != 0 {
+		t.Errorf("adding a known version = %v, %v", added, updated)
+	}
+	edited := review("a", 3, 10, 2, "Works after the update", "")
+	if added, updated := a.Add([]*androidpublisher.Review{edited}); added != 0 || updated != 1 {
+		t.Errorf("adding a new version = %v, %v", added, updated)
+	}
+	if err := a.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err = OpenArchive(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest := a.Latest()
+	if a.Len() != 2 || len(latest) != 2 || NewRow(latest[0]).Rating != 3 || len(a.Versions("a")) != 2 {
+		t.Errorf("unexpected archive %v", latest)
+	}
+}
+
+func TestConcurrentSaves(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "reviews.json")
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		a, err := OpenArchive(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a.Add([]*androidpublisher.Review{review(fmt.Sprint(i), 5, 10, 1, strings.Repeat("long review ", 1000), "")})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := a.Save(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if _, err := OpenArchive(path); err != nil {
+		t.Errorf("torn file after concurrent saves: %v", err)
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("temporary files left behind: %v", len(files))
+	}
+}
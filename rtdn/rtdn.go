@@ -0,0 +1,180 @@
+//Package rtdn receives and decodes real-time developer notifications pushed by Cloud Pub/Sub
+package rtdn
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+//DeveloperNotification is the payload published by Google Play, exactly one of the notifications is set
+type DeveloperNotification struct {
+	Version                    string                      `json:"version"`
+	PackageName                string                      `json:"packageName"`
+	EventTimeMillis            string                      `json:"eventTimeMillis"`
+	SubscriptionNotification   *SubscriptionNotification   `json:"subscriptionNotification,omitempty"`
+	OneTimeProductNotification *OneTimeProductNotification `json:"oneTimeProductNotification,omitempty"`
+	VoidedPurchaseNotification *VoidedPurchaseNotification `json:"voidedPurchaseNotification,omitempty"`
+	TestNotification           *TestNotification           `json:"testNotification,omitempty"`
+}
+
+//SubscriptionNotification is sent when the state of a subscription changes
+type SubscriptionNotification struct {
+	Version          string `json:"version"`
+	NotificationType int    `json:"notificationType"`
+	PurchaseToken    string `json:"purchaseToken"`
+	SubscriptionID   string `json:"subscriptionId"`
+}
+
+//OneTimeProductNotification is sent when a one-time product is bought or a pending purchase is cancelled
+type OneTimeProductNotification struct {
+	Version          string `json:"version"`
+	NotificationType int    `json:"notificationType"`
+	PurchaseToken    string `json:"purchaseToken"`
+	Sku              string `json:"sku"`
+}
+
+//VoidedPurchaseNotification is sent when a purchase is voided
+type VoidedPurchaseNotification struct {
+	PurchaseToken string `json:"purchaseToken"`
+	OrderID       string `json:"orderId"`
+	ProductType   int    `json:"productType"`
+	RefundType    int    `json:"refundType"`
+}
+
+//TestNotification is sent from the Play Console to check the setup
+type TestNotification struct {
+	Version string `json:"version"`
+}
+
+var subscriptionTypes = []string{"", "SUBSCRIPTION_RECOVERED", "SUBSCRIPTION_RENEWED", "SUBSCRIPTION_CANCELED",
+	"SUBSCRIPTION_PURCHASED", "SUBSCRIPTION_ON_HOLD", "SUBSCRIPTION_IN_GRACE_PERIOD", "SUBSCRIPTION_RESTARTED",
+	"SUBSCRIPTION_PRICE_CHANGE_CONFIRMED", "SUBSCRIPTION_DEFERRED", "SUBSCRIPTION_PAUSED",
+	"SUBSCRIPTION_PAUSE_SCHEDULE_CHANGED", "SUBSCRIPTION_REVOKED", "SUBSCRIPTION_EXPIRED"}
+
+var oneTimeProductTypes = []string{"", "ONE_TIME_PRODUCT_PURCHASED", "ONE_TIME_PRODUCT_CANCELED"}
+
+func typeName(names []string, t int) string {
+	if t > 0 && t < len(names) {
+		return names[t]
+	}
+	return fmt.Sprintf("UNKNOWN_%v", t)
+}
+
+//Type returns the name of the notification type, e.g. SUBSCRIPTION_RENEWED
+func (n *DeveloperNotification) Type() string {
+	switch {
+	case n.SubscriptionNotification != nil:
+		return typeName(subscriptionTypes, n.SubscriptionNotification.NotificationType)
+	case n.OneTimeProductNotification != nil:
+		return typeName(oneTimeProductTypes, n.OneTimeProductNotification.NotificationType)
+	case n.VoidedPurchaseNotification != nil:
+		return "VOIDED_PURCHASE"
+	case n.TestNotification != nil:
+		return "TEST"
+	}
+	return "UNKNOWN"
+}
+
+//Subject returns what the notification is about, e.g. the subscription id
+func (n *DeveloperNotification) Subject() string {
+	switch {
+	case n.SubscriptionNotification != nil:
+		return n.SubscriptionNotification.SubscriptionID
+	case n.OneTimeProductNotification != nil:
+		return n.OneTimeProductNotification.Sku
+	case n.VoidedPurchaseNotification != nil:
+		return n.VoidedPurchaseNotification.OrderID
+	}
+	return ""
+}
+
+//EventTime returns when the event happened
+func (n *DeveloperNotification) EventTime() time.Time {
+	ms, _ := strconv.ParseInt(n.EventTimeMillis, 10, 64)
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
+
+//Notification is a developer notification received through a Pub/Sub push request
+type Notification struct {
+	MessageID    string                `json:"messageId"`
+	PublishTime  string                `json:"publishTime,omitempty"`
+	Subscription string                `json:"subscription,omitempty"`
+	Received     time.Time             `json:"received"`
+	Developer    DeveloperNotification `json:"developerNotification"`
+}
+
+func (n Notification) String() string {
+	return fmt.Sprintf("%v %-36v %v", n.Received.Format("15:04:05"), n.Developer.Type(), n.Developer.Subject())
+}
+
+//push is the body of a Pub/Sub push request
+type push struct {
+	Message struct {
+		Data        string            `json:"data"`
+		MessageID   string            `json:"messageId"`
+		PublishTime string            `json:"publishTime"`
+		Attributes  map[string]string `json:"attributes"`
+	} `json:"message"`
+	Subscription string `json:"subscription"`
+}
+
+//Decode decodes the body of a Pub/Sub push request
+func Decode(body []byte) (*Notification, error) {
+	var p push
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, errors.Wrap(err, "invalid push request")
+	}
+	data, err := base64.StdEncoding.DecodeString(p.Message.Data)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid message data")
+	}
+	n := &Notification{MessageID: p.Message.MessageID, PublishTime: p.Message.PublishTime, Subscription: p.Subscription, Received: time.Now()}
+	if err := json.Unmarshal(data, &n.Developer); err != nil {
+		return nil, errors.Wrap(err, "invalid developer notification")
+	}
+	return n, nil
+}
+
+//Handler returns a handler that decodes push requests and passes the notifications to fn.
+//Undecodable requests are answered with 400 so that they show up in the Pub/Sub metrics.
+func Handler(fn func(Notification)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, 1<<20))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		n, err := Decode(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fn(*n)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+//Authorize only passes requests to h that have token in their token query parameter, the way a push subscription
+//with an endpoint like https://host/?token=<token> sends it. Other requests are answered with 403.
+func Authorize(token string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		given := r.URL.Query().Get("token")
+		if token == "" || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
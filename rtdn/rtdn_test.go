@@ -0,0 +1,81 @@
+package rtdn
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	var received []Notification
+	server := httptest.NewServer(Handler(func(n Notification) {
+		received = append(received, n)
+	}))
+	defer server.Close()
+
+	tests := map[string]struct{ typ, subject string }{
+		"subscription.json":     {"SUBSCRIPTION_RENEWED", "monthly"},
+		"one-time-product.json": {"ONE_TIME_PRODUCT_PURCHASED", "coins_100"},
+		"voided.json":           {"VOIDED_PURCHASE", "GPA.1234-5678-9012-34567"},
+		"test.json":             {"TEST", ""},
+	}
+	for name, want := range tests {
+		body, err := ioutil.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := http.Post(server.URL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != http.StatusNoContent {
+			t.Errorf("%v: status %v", name, res.StatusCode)
+			continue
+		}
+		n := received[len(received)-1]
+		if n.Developer.Type() != want.typ || n.Developer.Subject() != want.subject || n.Developer.PackageName != "com.example.android" {
+			t.Errorf("%v: decoded %v %v", name, n.Developer.Type(), n.Developer.Subject())
+		}
+	}
+
+	res, err := http.Post(server.URL, "application/json", bytes.NewReader([]byte(`{"message": {"data": "not base64!"}}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusBadRequest || len(received) != len(tests) {
+		t.Errorf("invalid push request answered with %v", res.StatusCode)
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	var received int
+	server := httptest.NewServer(Authorize("s3cret", Handler(func(n Notification) {
+		received++
+	})))
+	defer server.Close()
+	body, err := ioutil.ReadFile(filepath.Join("testdata", "test.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]int{
+		"/?token=s3cret":     http.StatusNoContent,
+		"/push?token=s3cret": http.StatusNoContent,
+		"/?token=wrong":      http.StatusForbidden,
+		"/":                  http.StatusForbidden,
+	}
+	for path, want := range tests {
+		res, err := http.Post(server.URL+path, "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != want {
+			t.Errorf("%v: status %v, expected %v", path, res.StatusCode, want)
+		}
+	}
+	if received != 2 {
+		t.Errorf("%v notifications passed, expected 2", received)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/atotto/clipboard"
+	"github.com/pkg/errors"
+	androidpublisher "google.golang.org/api/androidpublisher/v3"
+)
+
+//maxSharedArtifacts is the number of shared artifacts kept in the local list
+const maxSharedArtifacts = 50
+
+//sharedMu serializes updates of the list of shared artifacts
+var sharedMu sync.Mutex
+
+//SharedArtifact is a build uploaded for internal app sharing
+type SharedArtifact struct {
+	File                   string    `json:"file"`
+	Type                   string    `json:"type"`
+	DownloadURL            string    `json:"downloadUrl"`
+	CertificateFingerprint string    `json:"certificateFingerprint"`
+	Sha256                 string    `json:"sha256"`
+	Uploaded               time.Time `json:"uploaded"`
+	CopiedToClipboard      bool      `json:"copiedToClipboard,omitempty"`
+	//Warning is set when the artifact couldn't be added to the local list
+	Warning string `json:"warning,omitempty"`
+}
+
+func sharedPath(pkgName string) string {
+	return filepath.Join(dataDir(), "shared-"+pkgName+".json")
+}
+
+//sharedArtifacts returns the recently shared artifacts, newest first
+func sharedArtifacts(pkgName string) ([]SharedArtifact, error) {
+	var artifacts []SharedArtifact
+	data, err := ioutil.ReadFile(sharedPath(pkgName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &artifacts); err != nil {
+		return nil, errors.Wrap(err, "unable to read shared artifacts")
+	}
+	return artifacts, nil
+}
+
+//rememberArtifact adds an artifact to the list of recently shared ones
+func rememberArtifact(pkgName string, a SharedArtifact) error {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	artifacts, err := sharedArtifacts(pkgName)
+	if err != nil {
+		return err
+	}
+	artifacts = append([]SharedArtifact{a}, artifacts...)
+	if len(artifacts) > maxSharedArtifacts {
+		artifacts = artifacts[:maxSharedArtifacts]
+	}
+	data, err := json.MarshalIndent(artifacts, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dataDir(), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(sharedPath(pkgName), data, 0600)
+}
+
+//shareOperation uploads an APK or app bundle for internal app sharing and copies the download URL to the clipboard
+func shareOperation(service *androidpublisher.Service, pkgName, name, kind string) *Operation {
+	return &Operation{
+		Name:    name,
+		Params:  []*Param{{Name: "File", Required: true}},
+		Timeout: bulkTimeout,
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
+			f, progress, err := openUpload(ctx, params[0].Value)
+			if err != nil {
+				return nil, err
+			}
+			defer f.Close()
+			s := androidpublisher.NewInternalappsharingartifactsService(service)
+			var artifact *androidpublisher.InternalAppSharingArtifact
+			if kind == "apk" {
+				artifact, err = s.Uploadapk(pkgName).Media(f, uploadMedia).ProgressUpdater(progress).Context(ctx).Do()
+			} else {
+				artifact, err = s.Uploadbundle(pkgName).Media(f, uploadMedia).ProgressUpdater(progress).Context(ctx).Do()
+			}
+			if err != nil {
+				return nil, err
+			}
+			a := SharedArtifact{
+				File:                   params[0].Value,
+				Type:                   kind,
+				DownloadURL:            artifact.DownloadUrl,
+				CertificateFingerprint: artifact.CertificateFingerprint,
+				Sha256:                 artifact.Sha256,
+				Uploaded:               time.Now(),
+				CopiedToClipboard:      clipboard.WriteAll(artifact.DownloadUrl) == nil,
+			}
+			//the upload succeeded, so failing to remember it mustn't lose the download URL
+			if err := rememberArtifact(pkgName, a); err != nil {
+				a.Warning = "not added to the recent artifacts: " + err.Error()
+				status.UpdateError(a.Warning)
+			}
+			return a, nil
+		},
+	}
+}
+
+//recentArtifactsOperation lists the recently shared artifacts without calling the API
+func recentArtifactsOperation(pkgName string) *Operation {
+	return &Operation{
+		Name: "Recent",
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
+			sharedMu.Lock()
+			defer sharedMu.Unlock()
+			return sharedArtifacts(pkgName)
+		},
+	}
+}
@@ -0,0 +1,157 @@
+//Package subscription explains the fields of a subscription purchase in words
+package subscription
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	androidpublisher "google.golang.org/api/androidpublisher/v3"
+)
+
+//State is the lifecycle state of a subscription
+type State string
+
+//Lifecycle states of a subscription
+const (
+	Active        State = "active"
+	GracePeriod   State = "in grace period"
+	OnHold        State = "on hold"
+	Paused        State = "paused"
+	Cancelled     State = "cancelled, still entitled until expiry"
+	Expired       State = "expired"
+	PendingChange State = "active, upgrade/downgrade pending"
+)
+
+//Entitled reports whether the user has access to the subscription in this state
+func (s State) Entitled() bool {
+	switch s {
+	case Active, GracePeriod, Cancelled, PendingChange:
+		return true
+	}
+	return false
+}
+
+var (
+	paymentStates       = []string{"payment pending", "payment received", "free trial", "pending deferred upgrade/downgrade"}
+	cancelReasons       = []string{"cancelled by the user", "cancelled by the system, e.g. a billing problem", "replaced with a new subscription", "cancelled by the developer"}
+	cancelSurveyReasons = []string{"other", "doesn't use this service enough", "technical issues", "cost-related reasons", "found a better app"}
+	purchaseTypes       = []string{"test purchase with a license testing account", "bought with a promo code"}
+	acknowledgements    = []string{"not acknowledged yet", "acknowledged"}
+	priceChangeStates   = []string{"outstanding, the user hasn't agreed yet", "accepted"}
+	promotionTypes      = []string{"", "one-time code", "vanity code"}
+)
+
+func name(names []string, v int64) string {
+	if v >= 0 && int(v) < len(names) && names[v] != "" {
+		return names[v]
+	}
+	return fmt.Sprintf("unknown (%v)", v)
+}
+
+//StateOf works the lifecycle state out from the fields of a subscription at the given time
+func StateOf(sub *androidpublisher.SubscriptionPurchase, now time.Time) State {
+	expired := !millis(sub.ExpiryTimeMillis).After(now)
+	switch {
+	case sub.AutoResumeTimeMillis > 0 && expired:
+		return Paused
+	case sub.AutoRenewing && sub.PaymentState == 0 && !expired:
+		return GracePeriod
+	case sub.AutoRenewing && expired:
+		return OnHold
+	case expired:
+		return Expired
+	case !sub.AutoRenewing:
+		return Cancelled
+	case sub.PaymentState == 3:
+		return PendingChange
+	}
+	return Active
+}
+
+func millis(ms int64) time.Time {
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
+
+func formatTime(ms int64, now time.Time) string {
+	t := millis(ms)
+	d := t.Sub(now).Round(time.Minute)
+	rel := fmt.Sprintf("in %v", d)
+	if d < 0 {
+		rel = fmt.Sprintf("%v ago", -d)
+	}
+	return fmt.Sprintf("%v (%v)", t.UTC().Format("2006-01-02 15:04 MST"), rel)
+}
+
+//Explain describes a subscription purchase in words
+func Explain(sub *androidpublisher.SubscriptionPurchase, now time.Time) string {
+	var b strings.Builder
+	state := StateOf(sub, now)
+	entitled := "the user has access"
+	if !state.Entitled() {
+		entitled = "the user has no access"
+	}
+	fmt.Fprintf(&b, "State: %v, %v\n\n", strings.ToUpper(string(state)), entitled)
+
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	line := func(label, format string, args ...interface{}) {
+		fmt.Fprintf(w, "%v\t"+format+"\n", append([]interface{}{label}, args...)...)
+	}
+	line("Order", "%v", sub.OrderId)
+	line("Started", "%v", formatTime(sub.StartTimeMillis, now))
+	if state == Paused {
+		line("Paused, resumes", "%v", formatTime(sub.AutoResumeTimeMillis, now))
+	} else {
+		line("Expires", "%v", formatTime(sub.ExpiryTimeMillis, now))
+	}
+	if sub.AutoRenewing {
+		line("Renews", "yes, automatically at expiry")
+	} else {
+		line("Renews", "no")
+	}
+	if sub.PriceAmountMicros > 0 {
+		line("Price", "%.2f %v", float64(sub.PriceAmountMicros)/1e6, sub.PriceCurrencyCode)
+	}
+	if state != Expired && state != Cancelled {
+		line("Payment", "%v", name(paymentStates, sub.PaymentState))
+	}
+	if !sub.AutoRenewing || sub.UserCancellationTimeMillis > 0 {
+		line("Cancelled", "%v", name(cancelReasons, sub.CancelReason))
+		if sub.UserCancellationTimeMillis > 0 {
+			line("Cancelled at", "%v", formatTime(sub.UserCancellationTimeMillis, now))
+		}
+		if s := sub.CancelSurveyResult; s != nil {
+			reason := name(cancelSurveyReasons, s.CancelSurveyReason)
+			if s.UserInputCancelReason != "" {
+				reason += ": " + s.UserInputCancelReason
+			}
+			line("Survey answer", "%v", reason)
+		}
+	}
+	if sub.PurchaseType != nil {
+		line("Purchase type", "%v", name(purchaseTypes, *sub.PurchaseType))
+	}
+	if sub.PromotionType > 0 {
+		line("Promotion", "%v %v", name(promotionTypes, sub.PromotionType), sub.PromotionCode)
+	}
+	if p := sub.IntroductoryPriceInfo; p != nil {
+		line("Intro price", "%.2f %v for %v periods of %v", float64(p.IntroductoryPriceAmountMicros)/1e6, p.IntroductoryPriceCurrencyCode, p.IntroductoryPriceCycles, p.IntroductoryPricePeriod)
+	}
+	if p := sub.PriceChange; p != nil {
+		price := ""
+		if p.NewPrice != nil {
+			price = p.NewPrice.PriceMicros + " micros " + p.NewPrice.Currency + ", "
+		}
+		line("Price change", "%v%v", price, name(priceChangeStates, p.State))
+	}
+	line("Acknowledgement", "%v", name(acknowledgements, sub.AcknowledgementState))
+	if sub.CountryCode != "" {
+		line("Country", "%v", sub.CountryCode)
+	}
+	if sub.LinkedPurchaseToken != "" {
+		line("Replaces", "the purchase with token %v", sub.LinkedPurchaseToken)
+	}
+	w.Flush()
+	return b.String()
+}
@@ -0,0 +1,54 @@
+package subscription
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	androidpublisher "google.golang.org/api/androidpublisher/v3"
+)
+
+var now = time.Date(2019, 5, 20, 12, 0, 0, 0, time.UTC)
+
+func ms(d time.Duration) int64 {
+	return now.Add(d).UnixNano() / int64(time.Millisecond)
+}
+
+func TestStateOf(t *testing.T) {
+	day := 24 * time.Hour
+	tests := []struct {
+		sub  androidpublisher.SubscriptionPurchase
+		want State
+	}{
+		{androidpublisher.SubscriptionPurchase{ExpiryTimeMillis: ms(day), AutoRenewing: true, PaymentState: 1}, Active},
+		{androidpublisher.SubscriptionPurchase{ExpiryTimeMillis: ms(day), AutoRenewing: true, PaymentState: 2}, Active},
+		{androidpublisher.SubscriptionPurchase{ExpiryTimeMillis: ms(day), AutoRenewing: true, PaymentState: 3}, PendingChange},
+		{androidpublisher.SubscriptionPurchase{ExpiryTimeMillis: ms(day), AutoRenewing: true, PaymentState: 0}, GracePeriod},
+		{androidpublisher.SubscriptionPurchase{ExpiryTimeMillis: ms(-day), AutoRenewing: true}, OnHold},
+		{androidpublisher.SubscriptionPurchase{ExpiryTimeMillis: ms(-day), AutoRenewing: true, AutoResumeTimeMillis: ms(day)}, Paused},
+		{androidpublisher.SubscriptionPurchase{ExpiryTimeMillis: ms(day), PaymentState: 1}, Cancelled},
+		{androidpublisher.SubscriptionPurchase{ExpiryTimeMillis: ms(-day), CancelReason: 1}, Expired},
+	}
+	for i, test := range tests {
+		if got := StateOf(&test.sub, now); got != test.want {
+			t.Errorf("%v: StateOf() = %v, want %v", i, got, test.want)
+		}
+	}
+}
+
+func TestExplain(t *testing.T) {
+	sub := &androidpublisher.SubscriptionPurchase{
+		OrderId:                    "GPA.1234-5678-9012-34567..1",
+		ExpiryTimeMillis:           ms(48 * time.Hour),
+		UserCancellationTimeMillis: ms(-time.Hour),
+		CancelReason:               0,
+		CancelSurveyResult:         &androidpublisher.SubscriptionCancelSurveyResult{CancelSurveyReason: 3},
+		LinkedPurchaseToken:        "old-token",
+	}
+	text := Explain(sub, now)
+	for _, want := range []string{"CANCELLED, STILL ENTITLED UNTIL EXPIRY", "cancelled by the user", "cost-related reasons", "2019-05-22 12:00 UTC (in 48h0m0s)", "old-token"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("explanation doesn't contain %q:\n%v", want, text)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/atotto/clipboard"
+	"github.com/hassansin/androidpublisher/transport"
+	"github.com/hassansin/androidpublisher/ui"
+	"github.com/hassansin/gocui"
+	"github.com/logrusorgru/aurora"
+)
+
+//shownExchanges are the exchanges in the order the traffic list shows them, newer ones may have been recorded since
+var shownExchanges []transport.Exchange
+
+//exchangeItems takes a snapshot of the exchanges for the traffic list and returns its items
+func exchangeItems() []string {
+	shownExchanges = recorder.Exchanges()
+	items := make([]string, len(shownExchanges))
+	for i, e := range shownExchanges {
+		items[i] = e.String()
+	}
+	return items
+}
+
+//showTraffic opens the HTTP traffic inspector, picking an exchange shows its headers and bodies
+func showTraffic(g *gocui.Gui, v *gocui.View) error {
+	if trafficList != nil {
+		return nil
+	}
+	if len(recorder.Exchanges()) == 0 {
+		status.Update("No requests have been sent yet")
+		return nil
+	}
+	current := g.CurrentView()
+	trafficList = ui.NewList(g, "HTTP traffic", exchangeItems()).OnSelect(func(idx int) error {
+		trafficList = nil
+		e := shownExchanges[idx]
+		body := fmt.Sprintf("%v\n%v\n\n%v", e.Dump(), aurora.Bold("curl"), e.Curl())
+		mainView.Load(mainView.NewTab(), fmt.Sprintf("HTTP #%v", e.ID), ui.Text(body))
+		return mainView.SetCurrent()
+	}).OnCancel(func() error {
+		trafficList = nil
+		if current == nil {
+			return sideView.SetCurrent()
+		}
+		_, err := g.SetCurrentView(current.Name())
+		return err
+	}).Bind('y', func(idx int) error {
+		e := shownExchanges[idx]
+		if err := clipboard.WriteAll(e.Curl()); err != nil {
+			status.UpdateError(fmt.Sprintf("Unable to copy: %v", err))
+			return nil
+		}
+		status.UpdateSuccess(fmt.Sprintf("curl command for #%v copied to clipboard, the access token is read from $TOKEN", e.ID))
+		return nil
+	})
+	status.Update(fmt.Sprintf("%v:Show Exchange %v:Copy as curl %v:Close", aurora.Cyan("ENTER"), aurora.Cyan("y"), aurora.Cyan("ESC")))
+	return trafficList.Show()
+}
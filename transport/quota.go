@@ -0,0 +1,146 @@
+package transport
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//quotaLocation is the time zone the Play Developer API quotas reset in
+var quotaLocation = loadLocation("America/Los_Angeles")
+
+func loadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+//Usage is the number of API calls made on a day, per API family
+type Usage struct {
+	Day    string         `json:"day"`
+	Counts map[string]int `json:"counts"`
+}
+
+//Total returns the number of calls across all API families
+func (u Usage) Total() int {
+	total := 0
+	for _, n := range u.Counts {
+		total += n
+	}
+	return total
+}
+
+//Families returns the API families that have been called, sorted by name
+func (u Usage) Families() []string {
+	families := make([]string, 0, len(u.Counts))
+	for f := range u.Counts {
+		families = append(families, f)
+	}
+	sort.Strings(families)
+	return families
+}
+
+//Quota is a RoundTripper that counts Play Developer API calls per day and API family.
+//The counts are kept in a file so that they survive restarts.
+type Quota struct {
+	Base http.RoundTripper
+	//OnCall is called with the usage after every API call
+	OnCall func(Usage)
+
+	mu    sync.Mutex
+	path  string
+	usage Usage
+	now   func() time.Time
+}
+
+//NewQuota loads the usage saved in path
+func NewQuota(base http.RoundTripper, path string) *Quota {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	q := &Quota{Base: base, path: path, now: time.Now}
+	if data, err := ioutil.ReadFile(path); err == nil {
+		json.Unmarshal(data, &q.usage)
+	}
+	return q
+}
+
+//RoundTrip implements http.RoundTripper
+func (q *Quota) RoundTrip(req *http.Request) (*http.Response, error) {
+	family := Family(req.URL.Path)
+	if family == "" {
+		return q.Base.RoundTrip(req)
+	}
+	usage, err := q.add(family)
+	if err == nil && q.OnCall != nil {
+		q.OnCall(usage)
+	}
+	return q.Base.RoundTrip(req)
+}
+
+func (q *Quota) add(family string) (Usage, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.rollover()
+	q.usage.Counts[family]++
+	usage := q.copyUsage()
+	data, err := json.Marshal(q.usage)
+	if err != nil {
+		return usage, err
+	}
+	if err := os.MkdirAll(filepath.Dir(q.path), 0700); err != nil {
+		return usage, err
+	}
+	return usage, ioutil.WriteFile(q.path, data, 0600)
+}
+
+//Usage returns the calls made today
+func (q *Quota) Usage() Usage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.rollover()
+	return q.copyUsage()
+}
+
+//rollover resets the counts when the quota day has changed
+func (q *Quota) rollover() {
+	day := q.now().In(quotaLocation).Format("2006-01-02")
+	if q.usage.Day != day || q.usage.Counts == nil {
+		q.usage = Usage{Day: day, Counts: map[string]int{}}
+	}
+}
+
+func (q *Quota) copyUsage() Usage {
+	u := Usage{Day: q.usage.Day, Counts: make(map[string]int, len(q.usage.Counts))}
+	for k, v := range q.usage.Counts {
+		u.Counts[k] = v
+	}
+	return u
+}
+
+//Family returns the API family of a Play Developer API path, e.g. purchases.subscriptions
+//for /androidpublisher/v3/applications/com.example/purchases/subscriptions/sub/tokens/token
+func Family(path string) string {
+	const prefix = "/applications/"
+	idx := strings.Index(path, prefix)
+	if !strings.Contains(path, "/androidpublisher/") || idx < 0 {
+		return ""
+	}
+	parts := strings.Split(strings.Trim(path[idx+len(prefix):], "/"), "/")
+	if len(parts) < 2 {
+		return "applications"
+	}
+	family := strings.SplitN(parts[1], ":", 2)[0]
+	if family == "purchases" && len(parts) > 2 {
+		family += "." + parts[2]
+	}
+	return family
+}
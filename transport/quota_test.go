@@ -0,0 +1,72 @@
+package transport
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFamily(t *testing.T) {
+	cases := map[string]string{
+		"/androidpublisher/v3/applications/com.example/purchases/subscriptions/sub/tokens/t": "purchases.subscriptions",
+		"/androidpublisher/v3/applications/com.example/purchases/voidedpurchases":            "purchases.voidedpurchases",
+		"/androidpublisher/v3/applications/com.example/inappproducts/sku":                    "inappproducts",
+		"/androidpublisher/v3/applications/com.example/edits/123:commit":                     "edits",
+		"/androidpublisher/v3/applications/com.example/reviews/id:reply":                     "reviews",
+		"/upload/androidpublisher/v3/applications/com.example/edits/1/bundles":               "edits",
+		"/token": "",
+	}
+	for path, want := range cases {
+		if got := Family(path); got != want {
+			t.Errorf("Family(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestQuota(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "quota.json")
+	now := time.Date(2019, 4, 1, 10, 0, 0, 0, time.UTC)
+	q := NewQuota(nil, path)
+	q.now = func() time.Time { return now }
+	client := &http.Client{Transport: q}
+	for _, p := range []string{"/androidpublisher/v3/applications/a/reviews", "/androidpublisher/v3/applications/a/reviews/1", "/token"} {
+		if _, err := client.Get(srv.URL + p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if u := q.Usage(); u.Total() != 2 || u.Counts["reviews"] != 2 {
+		t.Errorf("unexpected usage %+v", u)
+	}
+
+	reloaded := NewQuota(nil, path)
+	reloaded.now = q.now
+	if u := reloaded.Usage(); u.Total() != 2 {
+		t.Errorf("usage was not saved: %+v", u)
+	}
+	now = now.Add(24 * time.Hour)
+	if u := reloaded.Usage(); u.Total() != 0 {
+		t.Errorf("usage was not reset the next day: %+v", u)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	rl := NewRateLimit(nil, 2, 2)
+	now := time.Now()
+	if rl.reserve(now) != 0 || rl.reserve(now) != 0 {
+		t.Error("burst requests must not wait")
+	}
+	if wait := rl.reserve(now); wait < 400*time.Millisecond || wait > 500*time.Millisecond {
+		t.Errorf("third request waits %v", wait)
+	}
+	if wait := rl.reserve(now.Add(500 * time.Millisecond)); wait != 0 {
+		t.Errorf("request after refill waits %v", wait)
+	}
+	if NewRateLimit(nil, 0, 0).reserve(now) != 0 {
+		t.Error("disabled limiter must not wait")
+	}
+}
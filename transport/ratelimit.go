@@ -0,0 +1,72 @@
+package transport
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+//RateLimit is a RoundTripper that limits the request rate with a token bucket
+type RateLimit struct {
+	Base http.RoundTripper
+
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+//NewRateLimit allows rate requests per second on average and bursts of up to burst requests.
+//A rate of 0 disables the limit.
+func NewRateLimit(base http.RoundTripper, rate float64, burst int) *RateLimit {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimit{
+		Base:   base,
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+//RoundTrip implements http.RoundTripper
+func (t *RateLimit) RoundTrip(req *http.Request) (*http.Response, error) {
+	for {
+		wait := t.reserve(time.Now())
+		if wait == 0 {
+			return t.Base.RoundTrip(req)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+//reserve takes a token if one is available, otherwise it returns how long to wait for the next one
+func (t *RateLimit) reserve(now time.Time) time.Duration {
+	if t.rate <= 0 {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tokens += now.Sub(t.last).Seconds() * t.rate
+	if t.tokens > t.burst {
+		t.tokens = t.burst
+	}
+	t.last = now
+	if t.tokens >= 1 {
+		t.tokens--
+		return 0
+	}
+	return time.Duration((1 - t.tokens) / t.rate * float64(time.Second))
+}
@@ -0,0 +1,224 @@
+package transport
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	maxExchanges = 200
+	maxBodySize  = 256 * 1024
+	redacted     = "REDACTED"
+)
+
+//Exchange is a recorded request and its response
+type Exchange struct {
+	ID             int
+	Method, URL    string
+	RequestHeader  http.Header
+	RequestBody    string
+	Status         string
+	StatusCode     int
+	ResponseHeader http.Header
+	ResponseBody   string
+	Err            error
+	Started        time.Time
+	Latency        time.Duration
+}
+
+func (e Exchange) String() string {
+	status := e.Status
+	if e.Err != nil {
+		status = "ERR"
+	}
+	return fmt.Sprintf("#%-3v %-6v %-3v %7v  %v", e.ID, e.Method, strings.SplitN(status, " ", 2)[0], e.Latency.Round(time.Millisecond), e.URL)
+}
+
+//Dump returns the request and the response the way they were sent and received
+func (e Exchange) Dump() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%v %v\n", e.Method, e.URL)
+	writeHeader(&b, e.RequestHeader)
+	if e.RequestBody != "" {
+		fmt.Fprintf(&b, "\n%v\n", e.RequestBody)
+	}
+	b.WriteString("\n")
+	if e.Err != nil {
+		fmt.Fprintf(&b, "Error: %v\n", e.Err)
+		return b.String()
+	}
+	fmt.Fprintf(&b, "%v (%v)\n", e.Status, e.Latency.Round(time.Millisecond))
+	writeHeader(&b, e.ResponseHeader)
+	if e.ResponseBody != "" {
+		fmt.Fprintf(&b, "\n%v\n", e.ResponseBody)
+	}
+	return b.String()
+}
+
+//Curl returns a curl command that repeats the request. The access token has to be provided in $TOKEN.
+func (e Exchange) Curl() string {
+	parts := []string{"curl", "-X", e.Method, shellQuote(e.URL)}
+	keys := sortedHeaderKeys(e.RequestHeader)
+	for _, k := range keys {
+		for _, v := range e.RequestHeader[k] {
+			if k == "Authorization" {
+				parts = append(parts, "-H", `"Authorization: Bearer $TOKEN"`)
+				continue
+			}
+			parts = append(parts, "-H", shellQuote(k+": "+v))
+		}
+	}
+	if e.RequestBody != "" {
+		parts = append(parts, "--data-binary", shellQuote(e.RequestBody))
+	}
+	return strings.Join(parts, " ")
+}
+
+//Recorder is a RoundTripper that keeps the most recent requests and responses.
+//Authorization headers are redacted before they're stored.
+type Recorder struct {
+	Base http.RoundTripper
+	//OnRecord is called whenever an exchange completes
+	OnRecord func(Exchange)
+
+	mu        sync.Mutex
+	exchanges []Exchange
+	next      int
+}
+
+//NewRecorder returns a Recorder wrapped around base
+func NewRecorder(base http.RoundTripper) *Recorder {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Recorder{Base: base}
+}
+
+//RoundTrip implements http.RoundTripper
+func (t *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	e := Exchange{
+		Method:        req.Method,
+		URL:           req.URL.String(),
+		RequestHeader: redact(req.Header),
+		Started:       time.Now(),
+	}
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			e.RequestBody = readBody(body, req.Header.Get("Content-Type"))
+		}
+	}
+	res, err := t.Base.RoundTrip(req)
+	e.Latency = time.Since(e.Started)
+	e.Err = err
+	if err == nil {
+		e.Status = res.Status
+		e.StatusCode = res.StatusCode
+		e.ResponseHeader = res.Header
+		body, readErr := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		res.Body = ioutil.NopCloser(bytes.NewReader(body))
+		if readErr == nil {
+			e.ResponseBody = formatBody(body, res.Header.Get("Content-Type"))
+		}
+	}
+
+	if sensitive(e.RequestBody) || sensitive(e.ResponseBody) {
+		e.RequestBody, e.ResponseBody = redacted, redacted
+	}
+
+	t.mu.Lock()
+	t.next++
+	e.ID = t.next
+	t.exchanges = append(t.exchanges, e)
+	if len(t.exchanges) > maxExchanges {
+		t.exchanges = t.exchanges[len(t.exchanges)-maxExchanges:]
+	}
+	t.mu.Unlock()
+	if t.OnRecord != nil {
+		t.OnRecord(e)
+	}
+	return res, err
+}
+
+//Exchanges returns the recorded exchanges, newest first
+func (t *Recorder) Exchanges() []Exchange {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	list := make([]Exchange, len(t.exchanges))
+	for i, e := range t.exchanges {
+		list[len(t.exchanges)-1-i] = e
+	}
+	return list
+}
+
+func redact(h http.Header) http.Header {
+	c := make(http.Header, len(h))
+	for k, v := range h {
+		if k == "Authorization" {
+			c[k] = []string{redacted}
+			continue
+		}
+		c[k] = append([]string(nil), v...)
+	}
+	return c
+}
+
+//sensitive reports whether body is part of an OAuth2 token exchange
+func sensitive(body string) bool {
+	return strings.Contains(body, "assertion=") || strings.Contains(body, `"access_token"`) ||
+		strings.Contains(body, "refresh_token")
+}
+
+func readBody(body io.ReadCloser, contentType string) string {
+	defer body.Close()
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return ""
+	}
+	return formatBody(data, contentType)
+}
+
+//formatBody keeps text bodies and replaces binary or large ones with their size
+func formatBody(data []byte, contentType string) string {
+	if len(data) == 0 {
+		return ""
+	}
+	text := contentType == "" || strings.HasPrefix(contentType, "text/") ||
+		strings.Contains(contentType, "json") || strings.Contains(contentType, "xml") ||
+		strings.Contains(contentType, "x-www-form-urlencoded")
+	if !text {
+		return fmt.Sprintf("<%v bytes of %v>", len(data), contentType)
+	}
+	if len(data) > maxBodySize {
+		return string(data[:maxBodySize]) + fmt.Sprintf("\n<truncated, %v bytes in total>", len(data))
+	}
+	return string(data)
+}
+
+func writeHeader(b *strings.Builder, h http.Header) {
+	for _, k := range sortedHeaderKeys(h) {
+		for _, v := range h[k] {
+			fmt.Fprintf(b, "%v: %v\n", k, v)
+		}
+	}
+}
+
+func sortedHeaderKeys(h http.Header) []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
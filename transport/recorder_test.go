@@ -0,0 +1,54 @@
+package transport
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"sku":"coins"}`))
+	}))
+	defer srv.Close()
+
+	rec := NewRecorder(nil)
+	client := &http.Client{Transport: rec}
+	req, _ := http.NewRequest("POST", srv.URL+"/inappproducts?alt=json", strings.NewReader(`{"sku":"it's"}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Content-Type", "application/json")
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	if string(body) != `{"sku":"coins"}` {
+		t.Errorf("response body was consumed: %q", body)
+	}
+
+	list := rec.Exchanges()
+	if len(list) != 1 {
+		t.Fatalf("recorded %v exchanges", len(list))
+	}
+	e := list[0]
+	if e.StatusCode != 200 || e.RequestBody != `{"sku":"it's"}` || e.ResponseBody != `{"sku":"coins"}` {
+		t.Errorf("unexpected exchange %+v", e)
+	}
+	if _, err := client.Post(srv.URL, "application/x-www-form-urlencoded", strings.NewReader("grant_type=jwt&assertion=secret")); err != nil {
+		t.Fatal(err)
+	}
+	if token := rec.Exchanges()[0]; token.RequestBody != redacted || token.ResponseBody != redacted {
+		t.Errorf("token exchange is not redacted: %+v", token)
+	}
+
+	if strings.Contains(e.Dump(), "secret") || strings.Contains(e.Curl(), "secret") {
+		t.Error("authorization header is not redacted")
+	}
+	want := `curl -X POST '` + srv.URL + `/inappproducts?alt=json' -H "Authorization: Bearer $TOKEN" -H 'Content-Type: application/json' --data-binary '{"sku":"it'\''s"}'`
+	if got := e.Curl(); got != want {
+		t.Errorf("Curl() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,160 @@
+//Package transport contains http.RoundTrippers that are wrapped around the API client
+package transport
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+//RetryPolicy decides which requests are retried and how long to wait between attempts
+type RetryPolicy struct {
+	//MaxRetries is the number of retries after the first attempt
+	MaxRetries int
+	//BaseDelay is the delay before the first retry, it doubles with every attempt up to MaxDelay
+	BaseDelay, MaxDelay time.Duration
+	//NonIdempotent also retries POST and PATCH requests
+	NonIdempotent bool
+	//Statuses are the response codes that are retried
+	Statuses []int
+}
+
+//DefaultRetryPolicy retries quota and transient server errors of idempotent requests
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	BaseDelay:  500 * time.Millisecond,
+	MaxDelay:   30 * time.Second,
+	Statuses:   []int{http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusServiceUnavailable},
+}
+
+//Retry retries failed requests with exponential backoff and jitter
+type Retry struct {
+	Base   http.RoundTripper
+	Policy RetryPolicy
+	//OnRetry is called before waiting for the next attempt
+	OnRetry func(req *http.Request, attempt int, wait time.Duration, reason string)
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+//NewRetry returns a Retry transport wrapped around base
+func NewRetry(base http.RoundTripper, policy RetryPolicy) *Retry {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Retry{
+		Base:   base,
+		Policy: policy,
+		rand:   rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+//RoundTrip implements http.RoundTripper
+func (t *Retry) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		//the request of the caller isn't modified, a retry sends a clone with a rewound body
+		r := req
+		if attempt > 0 && hasBody(req) {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, errors.Wrap(err, "unable to rewind request body")
+			}
+			r = req.Clone(req.Context())
+			r.Body = body
+		}
+		res, err := t.Base.RoundTrip(r)
+		if attempt >= t.Policy.MaxRetries || !t.retryable(req) {
+			return res, err
+		}
+		var reason string
+		var wait time.Duration
+		switch {
+		case err != nil:
+			if req.Context().Err() != nil {
+				return res, err
+			}
+			reason = err.Error()
+		case t.retryStatus(res.StatusCode):
+			reason = res.Status
+			wait = retryAfter(res.Header.Get("Retry-After"), time.Now())
+			res.Body.Close()
+		default:
+			return res, err
+		}
+		if wait == 0 {
+			wait = t.backoff(attempt)
+		}
+		if t.OnRetry != nil {
+			t.OnRetry(req, attempt+1, wait, reason)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+//hasBody reports whether the request has a body that has to be sent again, http.NoBody doesn't count
+func hasBody(req *http.Request) bool {
+	return req.Body != nil && req.Body != http.NoBody
+}
+
+//retryable reports whether the request can be sent again safely
+func (t *Retry) retryable(req *http.Request) bool {
+	if hasBody(req) && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return t.Policy.NonIdempotent
+}
+
+func (t *Retry) retryStatus(code int) bool {
+	for _, s := range t.Policy.Statuses {
+		if s == code {
+			return true
+		}
+	}
+	return false
+}
+
+//backoff returns a random delay between 0 and BaseDelay*2^attempt, capped at MaxDelay
+func (t *Retry) backoff(attempt int) time.Duration {
+	max := t.Policy.BaseDelay << uint(attempt)
+	if max > t.Policy.MaxDelay || max <= 0 {
+		max = t.Policy.MaxDelay
+	}
+	if max <= 0 {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.rand == nil {
+		t.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return time.Duration(t.rand.Int63n(int64(max)))
+}
+
+//retryAfter parses the Retry-After header, which is either a number of seconds or an HTTP date
+func retryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,143 @@
+package transport
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	policy := DefaultRetryPolicy
+	policy.BaseDelay = time.Millisecond
+	rt := NewRetry(nil, policy)
+	var retries []int
+	rt.OnRetry = func(req *http.Request, attempt int, wait time.Duration, reason string) {
+		retries = append(retries, attempt)
+	}
+	client := &http.Client{Transport: rt}
+
+	res, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusOK || calls != 3 || len(retries) != 2 {
+		t.Errorf("status %v after %v calls and retries %v", res.StatusCode, calls, retries)
+	}
+
+	calls = 0
+	res, err = client.Post(srv.URL, "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusServiceUnavailable || calls != 1 {
+		t.Errorf("POST retried: status %v after %v calls", res.StatusCode, calls)
+	}
+
+	calls = 0
+	rt.Policy.NonIdempotent = true
+	res, err = client.Post(srv.URL, "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusOK || calls != 3 {
+		t.Errorf("POST not retried: status %v after %v calls", res.StatusCode, calls)
+	}
+}
+
+//recordingTransport fails with 503 until the last response and keeps the requests it was sent
+type recordingTransport struct {
+	fail     int
+	requests []*http.Request
+	bodies   []string
+}
+
+func (rt *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.requests = append(rt.requests, req)
+	body := ""
+	if req.Body != nil {
+		data, _ := ioutil.ReadAll(req.Body)
+		body = string(data)
+	}
+	rt.bodies = append(rt.bodies, body)
+	code := http.StatusOK
+	if len(rt.requests) <= rt.fail {
+		code = http.StatusServiceUnavailable
+	}
+	return &http.Response{StatusCode: code, Status: http.StatusText(code), Body: ioutil.NopCloser(strings.NewReader("")), Header: http.Header{}}, nil
+}
+
+func TestRetryDoesNotModifyRequest(t *testing.T) {
+	base := &recordingTransport{fail: 2}
+	policy := DefaultRetryPolicy
+	policy.BaseDelay = time.Millisecond
+	rt := NewRetry(base, policy)
+
+	req, err := http.NewRequest(http.MethodPut, "http://example.com", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := req.Body
+	res, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusOK || len(base.requests) != 3 {
+		t.Fatalf("status %v after %v requests", res.StatusCode, len(base.requests))
+	}
+	if req.Body != body {
+		t.Error("the body of the request was replaced")
+	}
+	for i, b := range base.bodies {
+		if b != "payload" {
+			t.Errorf("attempt %v sent body %q", i+1, b)
+		}
+	}
+	if base.requests[1] == req || base.requests[2] == req {
+		t.Error("a retry sent the request of the caller")
+	}
+
+	//NoBody without GetBody is retried without rewinding
+	base = &recordingTransport{fail: 1}
+	rt.Base = base
+	req, err = http.NewRequest(http.MethodGet, "http://example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Body, req.GetBody = http.NoBody, nil
+	if res, err = rt.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusOK || len(base.requests) != 2 {
+		t.Errorf("status %v after %v requests", res.StatusCode, len(base.requests))
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2019, 4, 1, 10, 0, 0, 0, time.UTC)
+	cases := map[string]time.Duration{
+		"":                              0,
+		"120":                           2 * time.Minute,
+		"Mon, 01 Apr 2019 10:00:30 GMT": 30 * time.Second,
+		"Mon, 01 Apr 2019 09:00:00 GMT": 0,
+		"soon":                          0,
+	}
+	for value, want := range cases {
+		if got := retryAfter(value, now); got != want {
+			t.Errorf("retryAfter(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hassansin/androidpublisher/diff"
+	"github.com/hassansin/gocui"
+)
+
+const maxHistory = 20
+
+//response is a previously loaded response that can be pinned for comparison
+type response struct {
+	title string
+	body  interface{}
+	at    time.Time
+}
+
+func (r *response) String() string {
+	return fmt.Sprintf("%v %v", r.at.Format("15:04:05"), r.title)
+}
+
+//remember adds a successful response to the history
+func (m *MainView) remember(title string, body interface{}) {
+	if _, ok := body.(error); ok || body == nil {
+		return
+	}
+	m.history = append(m.history, &response{title: title, body: body, at: time.Now()})
+	if len(m.history) > maxHistory {
+		m.history = m.history[len(m.history)-maxHistory:]
+	}
+}
+
+//Pin splits the view and compares every following response with the idx-th response from history
+func (m *MainView) Pin(idx int) error {
+	if idx < 0 || idx >= len(m.history) {
+		return nil
+	}
+	m.pinned = m.history[idx]
+	if err := m.SetView(); err != nil {
+		return err
+	}
+	m.pinnedView.Title = fmt.Sprintf("Pinned(%v)", m.pinned)
+	m.refresh()
+	return nil
+}
+
+func (m *MainView) unpin(g *gocui.Gui, v *gocui.View) error {
+	if m.pinned == nil {
+		return nil
+	}
+	m.pinned = nil
+	m.pinnedView = nil
+	if err := deleteView(g, m.pinnedName); err != nil {
+		return err
+	}
+	if err := m.SetView(); err != nil {
+		return err
+	}
+	m.refresh()
+	if m.onCompare != nil {
+		m.onCompare("", nil)
+	}
+	return nil
+}
+
+func (m *MainView) setPinnedView(x0, x1, y1 int) error {
+	v, err := m.g.SetView(m.pinnedName, x0, 0, x1, y1)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		v.Frame = true
+		v.Wrap = true
+	}
+	m.pinnedView = v
+	return nil
+}
+
+//compare renders the current response and the pinned one as a structural diff
+func (m *MainView) compare() {
+	old, err := m.document(m.pinned.body)
+	if err != nil {
+		m.pinnedView.Clear()
+		fmt.Fprint(m.pinnedView, err.Error())
+		return
+	}
+	body := m.tab().body
+	if _, ok := body.(error); ok || body == nil {
+		m.pinnedView.Clear()
+		fmt.Fprint(m.pinnedView, diff.Render(old, nil, diff.Left))
+		return
+	}
+	cur, err := m.document(body)
+	if err != nil {
+		return
+	}
+	changes := diff.Compare(old, cur)
+	m.content = diff.Render(cur, changes, diff.Right)
+	m.pinnedView.Clear()
+	m.pinnedView.SetOrigin(0, 0)
+	fmt.Fprint(m.pinnedView, diff.Render(old, changes, diff.Left))
+	if m.onCompare != nil {
+		m.onCompare(m.pinned.String(), changes)
+	}
+}
+
+//document returns the normalized and filtered form of body that is used for comparison
+func (m *MainView) document(body interface{}) (interface{}, error) {
+	values, err := m.filtered(body)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 1 {
+		return diff.Normalize(values[0])
+	}
+	return diff.Normalize(values)
+}
+
+//syncPinned scrolls the pinned response along with the current one
+func (m *MainView) syncPinned() {
+	if m.pinnedView == nil {
+		return
+	}
+	_, oy := m.View.Origin()
+	if lines := len(m.pinnedView.ViewBufferLines()); oy >= lines {
+		oy = lines - 1
+	}
+	if oy < 0 {
+		oy = 0
+	}
+	m.pinnedView.SetOrigin(0, oy)
+}
+
+//OnCompare binds function to be called with the changes between the pinned and the current response
+func (m *MainView) OnCompare(fn func(pinned string, changes []diff.Change)) *MainView {
+	m.onCompare = fn
+	return m
+}
+
+func (m *MainView) historyDialog(g *gocui.Gui, v *gocui.View) error {
+	items := make([]string, len(m.history))
+	for i, res := range m.history {
+		items[len(items)-1-i] = res.String()
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	return NewList(g, "Pin response", items).OnSelect(func(idx int) error {
+		if err := m.Pin(len(m.history) - 1 - idx); err != nil {
+			return err
+		}
+		return m.SetCurrent()
+	}).OnCancel(m.SetCurrent).Show()
+}
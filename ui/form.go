@@ -68,6 +68,7 @@ type Input struct {
 	Mask              rune
 	Name              string
 	Value             *string
+	OnChange          func(string)
 	view              *gocui.View
 }
 
@@ -84,7 +85,12 @@ func (f *Form) Input(input *Input) error {
 		}
 		v.Wrap = true
 		v.Editable = true
-		v.Editor = gocui.EditorFunc(inputEditor)
+		v.Editor = gocui.EditorFunc(func(v *gocui.View, key gocui.Key, ch rune, mod gocui.Modifier) {
+			inputEditor(v, key, ch, mod)
+			if input.OnChange != nil {
+				input.OnChange(strings.TrimSpace(v.Buffer()))
+			}
+		})
 		v.Mask = input.Mask
 		if err := f.g.SetKeybinding(v.Name(), gocui.KeyEsc, gocui.ModNone, f.cancel); err != nil {
 			return err
@@ -0,0 +1,174 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hassansin/gocui"
+	"github.com/pkg/errors"
+)
+
+//List is a popup that lets the user pick one of the items
+type List struct {
+	g        *gocui.Gui
+	title    string
+	items    []string
+	name     string
+	view     *gocui.View
+	bindings []listBinding
+	onSelect func(int) error
+	onCancel func() error
+}
+
+type listBinding struct {
+	key interface{}
+	fn  func(int) error
+}
+
+//NewList returns a new List
+func NewList(g *gocui.Gui, title string, items []string) *List {
+	return &List{
+		g:     g,
+		title: title,
+		items: items,
+		name:  fmt.Sprintf("list-%v", r.Int()),
+	}
+}
+
+//OnSelect binds function to be called with the index of the picked item
+func (l *List) OnSelect(fn func(int) error) *List {
+	l.onSelect = fn
+	return l
+}
+
+//OnCancel binds function to be called when the list is closed without picking an item
+func (l *List) OnCancel(fn func() error) *List {
+	l.onCancel = fn
+	return l
+}
+
+//Bind calls fn with the index of the highlighted item when key is pressed
+func (l *List) Bind(key interface{}, fn func(int) error) *List {
+	l.bindings = append(l.bindings, listBinding{key, fn})
+	return l
+}
+
+//SetItems replaces the items, keeping the cursor within the new list
+func (l *List) SetItems(items []string) {
+	l.items = items
+	if l.view == nil {
+		return
+	}
+	l.view.Clear()
+	fmt.Fprint(l.view, strings.Join(items, "\n"))
+	if idx := l.Selected(); idx >= len(items) && len(items) > 0 {
+		l.view.SetOrigin(0, 0)
+		l.view.SetCursor(0, len(items)-1)
+	}
+}
+
+//Show draws the list in the middle of the screen and focuses it
+func (l *List) Show() error {
+	maxX, maxY := l.g.Size()
+	width := len(l.title) + 4
+	for _, item := range l.items {
+		if w := len([]rune(strip(item))) + 2; w > width {
+			width = w
+		}
+	}
+	if width > maxX-4 {
+		width = maxX - 4
+	}
+	height := len(l.items) + 1
+	if height > maxY-6 {
+		height = maxY - 6
+	}
+	x0, y0 := maxX/2-width/2, maxY/2-height/2
+	v, err := l.g.SetView(l.name, x0, y0, x0+width, y0+height)
+	if err != nil && err != gocui.ErrUnknownView {
+		return errors.Wrap(err, "unable to create list view")
+	}
+	v.Frame = true
+	v.Title = l.title
+	v.Highlight = true
+	v.SelBgColor = gocui.ColorGreen
+	v.SelFgColor = gocui.ColorBlack
+	fmt.Fprint(v, strings.Join(l.items, "\n"))
+	l.view = v
+
+	bindings := []struct {
+		key     interface{}
+		handler func(*gocui.Gui, *gocui.View) error
+	}{
+		{gocui.KeyArrowDown, l.cursorDown},
+		{'j', l.cursorDown},
+		{gocui.KeyArrowUp, l.cursorUp},
+		{'k', l.cursorUp},
+		{gocui.KeyEnter, l.selectItem},
+		{gocui.KeyEsc, l.cancel},
+	}
+	for _, b := range bindings {
+		if err := l.g.SetKeybinding(l.name, b.key, gocui.ModNone, b.handler); err != nil {
+			return err
+		}
+	}
+	for _, b := range l.bindings {
+		fn := b.fn
+		if err := l.g.SetKeybinding(l.name, b.key, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+			if idx := l.Selected(); idx < len(l.items) {
+				return fn(idx)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	_, err = l.g.SetCurrentView(l.name)
+	return err
+}
+
+//Selected returns the index of the highlighted item
+func (l *List) Selected() int {
+	_, oy := l.view.Origin()
+	_, cy := l.view.Cursor()
+	return oy + cy
+}
+
+//Close removes the list without calling any callback
+func (l *List) Close() error {
+	return deleteView(l.g, l.name)
+}
+
+func (l *List) cursorDown(g *gocui.Gui, v *gocui.View) error {
+	if l.Selected() >= len(l.items)-1 {
+		return nil
+	}
+	v.MoveCursor(0, 1, false)
+	return nil
+}
+
+func (l *List) cursorUp(g *gocui.Gui, v *gocui.View) error {
+	v.MoveCursor(0, -1, false)
+	return nil
+}
+
+func (l *List) selectItem(g *gocui.Gui, v *gocui.View) error {
+	idx := l.Selected()
+	if err := deleteView(g, l.name); err != nil {
+		return err
+	}
+	if l.onSelect != nil && idx < len(l.items) {
+		return l.onSelect(idx)
+	}
+	return nil
+}
+
+func (l *List) cancel(g *gocui.Gui, v *gocui.View) error {
+	if err := deleteView(g, l.name); err != nil {
+		return err
+	}
+	if l.onCancel != nil {
+		return l.onCancel()
+	}
+	return nil
+}
@@ -6,9 +6,13 @@ import (
 	"math/rand"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/atotto/clipboard"
+	"github.com/hassansin/androidpublisher/apierror"
+	"github.com/hassansin/androidpublisher/diff"
+	"github.com/hassansin/androidpublisher/filter"
 	"github.com/hassansin/androidpublisher/movements"
 	"github.com/hassansin/gocui"
 	"github.com/nwidger/jsoncolor"
@@ -17,19 +21,35 @@ import (
 
 var r = rand.New(rand.NewSource(time.Now().UnixNano()))
 
+//Text is a response that is displayed as it is instead of as JSON
+type Text string
+
 type MainView struct {
 	*gocui.View
 	scrollbarView *Scrollbar
 	g             *gocui.Gui
 	name          string
-	body          interface{}
+	tabs          []*Tab
+	current       int
+	content       string
+	search        *search
+	filter        *filter.Filter
+	history       []*response
+	pinned        *response
+	pinnedView    *gocui.View
+	pinnedName    string
 	onSave        func(string, error)
+	onSearch      func(string, int, int)
+	onFilter      func(string, error)
+	onCompare     func(string, []diff.Change)
 }
 
 func NewMainView(g *gocui.Gui) *MainView {
 	v := &MainView{
-		g:    g,
-		name: fmt.Sprintf("main-%v", r.Int()),
+		g:          g,
+		name:       fmt.Sprintf("main-%v", r.Int()),
+		pinnedName: fmt.Sprintf("pinned-%v", r.Int()),
+		tabs:       []*Tab{{}},
 	}
 	return v
 }
@@ -38,7 +58,15 @@ func (m *MainView) Name() string {
 }
 func (m *MainView) SetView() error {
 	maxX, maxY := m.g.Size()
-	if v, err := m.g.SetView(m.Name(), 30, 0, maxX-1, maxY-2); err != nil {
+	x0 := 30
+	if m.pinned != nil {
+		mid := x0 + (maxX-1-x0)/2
+		if err := m.setPinnedView(x0, mid, maxY-2); err != nil {
+			return err
+		}
+		x0 = mid + 1
+	}
+	if v, err := m.g.SetView(m.Name(), x0, 0, maxX-1, maxY-2); err != nil {
 		if err != gocui.ErrUnknownView {
 			return err
 		}
@@ -78,6 +106,42 @@ func (m *MainView) SetKeybinding() error {
 	if err := m.g.SetKeybinding(name, gocui.KeyCtrlE, gocui.ModNone, m.moveWithScroll(movements.End)); err != nil {
 		return err
 	}
+	if err := m.g.SetKeybinding(name, '/', gocui.ModNone, m.searchDialog); err != nil {
+		return err
+	}
+	if err := m.g.SetKeybinding(name, 'f', gocui.ModNone, m.filterDialog); err != nil {
+		return err
+	}
+	if err := m.g.SetKeybinding(name, 'p', gocui.ModNone, m.historyDialog); err != nil {
+		return err
+	}
+	if err := m.g.SetKeybinding(name, 'u', gocui.ModNone, m.unpin); err != nil {
+		return err
+	}
+	if err := m.g.SetKeybinding(name, ']', gocui.ModNone, m.nextTab); err != nil {
+		return err
+	}
+	if err := m.g.SetKeybinding(name, '[', gocui.ModNone, m.prevTab); err != nil {
+		return err
+	}
+	if err := m.g.SetKeybinding(name, '>', gocui.ModNone, m.moveTabRight); err != nil {
+		return err
+	}
+	if err := m.g.SetKeybinding(name, '<', gocui.ModNone, m.moveTabLeft); err != nil {
+		return err
+	}
+	if err := m.g.SetKeybinding(name, 't', gocui.ModNone, m.newTab); err != nil {
+		return err
+	}
+	if err := m.g.SetKeybinding(name, 'x', gocui.ModNone, m.closeTab); err != nil {
+		return err
+	}
+	if err := m.g.SetKeybinding(name, 'n', gocui.ModNone, m.nextMatch); err != nil {
+		return err
+	}
+	if err := m.g.SetKeybinding(name, 'N', gocui.ModNone, m.prevMatch); err != nil {
+		return err
+	}
 	if err := m.g.SetKeybinding("", gocui.KeyCtrlS, gocui.ModNone, m.saveDialog); err != nil {
 		return err
 	}
@@ -92,6 +156,7 @@ func (m *MainView) moveWithScroll(fn func(*gocui.Gui, *gocui.View) error) func(*
 		if err := fn(g, v); err != nil {
 			return err
 		}
+		m.syncPinned()
 		return m.scrollbarView.Redraw()
 	}
 }
@@ -101,26 +166,262 @@ func (m *MainView) SetCurrent() error {
 	return errors.Wrap(err, "failed to current view to main")
 }
 
+//LoadContent loads the response into the current tab
 func (m *MainView) LoadContent(name string, res interface{}) {
-	var result string
-	if err, ok := res.(error); ok {
-		result = err.Error()
+	m.Load(m.tab(), name, res)
+}
+
+//Load loads the response into the given tab, the view is only redrawn if the tab is the current one
+func (m *MainView) Load(t *Tab, name string, res interface{}) {
+	t.title = name
+	t.body = res
+	t.origin = 0
+	m.remember(name, res)
+	if t == m.tab() {
+		m.refresh()
+		return
+	}
+	m.setTitle()
+}
+
+func (m *MainView) setTitle() {
+	m.View.Title = fmt.Sprintf("Response %v", m.tabBar())
+	if m.filter != nil {
+		m.View.Title = fmt.Sprintf("Response %v | %v", m.tabBar(), m.filter)
+	}
+}
+
+//refresh re-renders the response of the current tab through the current filter
+func (m *MainView) refresh() {
+	m.setTitle()
+	m.content = m.format()
+	if m.pinned != nil {
+		m.compare()
+	}
+	m.search = nil
+	m.render()
+	m.View.SetCursor(0, 0)
+	m.View.SetOrigin(0, 0)
+	m.g.Update(func(g *gocui.Gui) error {
+		return m.scrollbarView.Redraw()
+	})
+}
+
+func (m *MainView) format() string {
+	body := m.tab().body
+	if err, ok := body.(error); ok {
+		return apierror.Render(err)
+	}
+	if text, ok := body.(Text); ok {
+		return string(text)
+	}
+	values, err := m.filtered(body)
+	if err != nil {
+		return err.Error()
+	}
+	parts := make([]string, len(values))
+	for i, value := range values {
+		body, err := jsoncolor.MarshalIndent(value, "", " ")
+		if err != nil {
+			return err.Error()
+		}
+		parts[i] = string(body)
+	}
+	return strings.Join(parts, "\n")
+}
+
+//filtered returns the values the current filter produces for body
+func (m *MainView) filtered(body interface{}) ([]interface{}, error) {
+	if m.filter == nil || body == nil {
+		return []interface{}{body}, nil
+	}
+	return m.filter.Apply(body)
+}
+
+//SetFilter sets the expression applied to every response, an empty expression removes the filter
+func (m *MainView) SetFilter(expr string) error {
+	if expr == "" {
+		m.filter = nil
 	} else {
-		body, err := jsoncolor.MarshalIndent(res, "", " ")
+		f, err := filter.Parse(expr)
 		if err != nil {
-			result = err.Error()
-		} else {
-			result = string(body)
+			return err
 		}
+		m.filter = f
+	}
+	if m.View != nil {
+		m.refresh()
 	}
-	m.View.Title = fmt.Sprintf("Response(%v)", name)
+	return nil
+}
+
+//Filter returns the current filter expression
+func (m *MainView) Filter() string {
+	if m.filter == nil {
+		return ""
+	}
+	return m.filter.String()
+}
+
+//render writes the content to the view, highlighting search matches if any
+func (m *MainView) render() {
 	m.View.Clear()
-	m.View.SetCursor(0, 0)
-	m.View.SetOrigin(0, 0)
-	fmt.Fprintf(m.View, result)
+	if m.search == nil || len(m.search.matches) == 0 {
+		fmt.Fprint(m.View, m.content)
+		return
+	}
+	lines := strings.Split(m.content, "\n")
+	cols := map[int][]int{}
+	styles := map[int][]string{}
+	for i, mt := range m.search.matches {
+		style := matchStyle
+		if i == m.search.current {
+			style = currentStyle
+		}
+		cols[mt.line] = append(cols[mt.line], mt.col)
+		styles[mt.line] = append(styles[mt.line], style)
+	}
+	length := len([]rune(m.search.query))
+	for i, line := range lines {
+		lines[i] = highlightLine(line, cols[i], length, styles[i])
+	}
+	fmt.Fprint(m.View, strings.Join(lines, "\n"))
+}
+
+//Search highlights all occurrences of query and moves to the first one
+func (m *MainView) Search(query string) error {
+	if query == "" {
+		m.search = nil
+		m.render()
+		m.notifySearch()
+		return nil
+	}
+	m.search = &search{
+		query:   query,
+		matches: findMatches(strings.Split(strip(m.content), "\n"), query),
+	}
+	m.render()
+	m.notifySearch()
+	return m.jump()
+}
+
+func (m *MainView) nextMatch(g *gocui.Gui, v *gocui.View) error {
+	return m.moveMatch(1)
+}
+
+func (m *MainView) prevMatch(g *gocui.Gui, v *gocui.View) error {
+	return m.moveMatch(-1)
+}
+
+func (m *MainView) moveMatch(delta int) error {
+	if m.search == nil || len(m.search.matches) == 0 {
+		return nil
+	}
+	total := len(m.search.matches)
+	m.search.current = (m.search.current + delta + total) % total
+	m.render()
+	m.notifySearch()
+	return m.jump()
+}
+
+//jump scrolls the view so that the current match is visible
+func (m *MainView) jump() error {
+	if m.search == nil || len(m.search.matches) == 0 {
+		return nil
+	}
+	mt := m.search.matches[m.search.current]
+	w, h := m.View.Size()
+	row := viewLine(strings.Split(strip(m.content), "\n"), w, mt)
+	_, oy := m.View.Origin()
+	if row < oy || row >= oy+h {
+		oy = row - h/2
+		if oy < 0 {
+			oy = 0
+		}
+	}
+	if err := m.View.SetOrigin(0, oy); err != nil {
+		return err
+	}
+	col := mt.col
+	if w > 0 {
+		col = col % w
+	}
+	if err := m.View.SetCursor(col, row-oy); err != nil {
+		return err
+	}
 	m.g.Update(func(g *gocui.Gui) error {
 		return m.scrollbarView.Redraw()
 	})
+	return nil
+}
+
+func (m *MainView) notifySearch() {
+	if m.onSearch == nil {
+		return
+	}
+	if m.search == nil {
+		m.onSearch("", 0, 0)
+		return
+	}
+	m.onSearch(m.search.query, m.search.current+1, len(m.search.matches))
+}
+
+//OnSearch binds function to be called when the search query or the current match changes
+func (m *MainView) OnSearch(fn func(query string, current, total int)) *MainView {
+	m.onSearch = fn
+	return m
+}
+
+//OnFilter binds function to be called when the filter expression changes
+func (m *MainView) OnFilter(fn func(string, error)) *MainView {
+	m.onFilter = fn
+	return m
+}
+
+func (m *MainView) filterDialog(g *gocui.Gui, v *gocui.View) error {
+	maxX, maxY := g.Size()
+	f, err := NewForm(g, "Filter", maxX/2-30, maxY/2)
+	if err != nil {
+		return err
+	}
+	f.OnCancel(func() error {
+		return m.SetCurrent()
+	})
+	expr := m.Filter()
+	f.OnSubmit(func() error {
+		err := m.SetFilter(expr)
+		if m.onFilter != nil {
+			m.onFilter(expr, err)
+		}
+		return m.SetCurrent()
+	})
+	return f.Input(NewInput("Expression (e.g. .inappproduct[].sku)", &expr, 60, true))
+}
+
+func (m *MainView) searchDialog(g *gocui.Gui, v *gocui.View) error {
+	maxX, maxY := g.Size()
+	f, err := NewForm(g, "Search", maxX/2-20, maxY/2)
+	if err != nil {
+		return err
+	}
+	f.OnCancel(func() error {
+		if err := m.Search(""); err != nil {
+			return err
+		}
+		return m.SetCurrent()
+	})
+	var query string
+	f.OnSubmit(func() error {
+		if err := m.Search(query); err != nil {
+			return err
+		}
+		return m.SetCurrent()
+	})
+	input := NewInput("Search", &query, 40, true)
+	input.OnChange = func(value string) {
+		m.Search(value)
+	}
+	return f.Input(input)
 }
 
 func (m *MainView) SaveContent(filename string) error {
@@ -0,0 +1,15 @@
+package ui
+
+import "strings"
+
+//ProgressBar returns a bar width cells wide, filled in proportion to done/total
+func ProgressBar(done, total, width int) string {
+	filled := 0
+	if total > 0 {
+		filled = done * width / total
+	}
+	if filled > width {
+		filled = width
+	}
+	return "[" + strings.Repeat("#", filled) + strings.Repeat("-", width-filled) + "]"
+}
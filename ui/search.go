@@ -0,0 +1,107 @@
+package ui
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	matchStyle   = "\x1b[7m"
+	currentStyle = "\x1b[30;43m"
+	resetStyle   = "\x1b[0m"
+)
+
+//match is the position of a search hit, in runes, inside a content line
+type match struct {
+	line, col int
+}
+
+//search holds the state of an incremental search in a view
+type search struct {
+	query   string
+	matches []match
+	current int
+}
+
+//findMatches returns every case-insensitive occurrence of query in lines.
+//lines must not contain escape sequences.
+func findMatches(lines []string, query string) []match {
+	var matches []match
+	if query == "" {
+		return matches
+	}
+	q := strings.ToLower(query)
+	for i, line := range lines {
+		l := strings.ToLower(line)
+		for offset := 0; ; {
+			idx := strings.Index(l[offset:], q)
+			if idx < 0 {
+				break
+			}
+			matches = append(matches, match{line: i, col: utf8.RuneCountInString(l[:offset+idx])})
+			offset += idx + len(q)
+		}
+	}
+	return matches
+}
+
+//highlightLine wraps the runes in [col, col+length) for every col in cols with
+//the given style, preserving any escape sequences that colour the rest of the line
+func highlightLine(line string, cols []int, length int, styles []string) string {
+	if len(cols) == 0 {
+		return line
+	}
+	escapes := re.FindAllStringIndex(line, -1)
+	var b strings.Builder
+	var last string
+	pos, next, end := 0, 0, -1
+	for i := 0; i < len(line); {
+		if len(escapes) > 0 && escapes[0][0] == i {
+			seq := line[escapes[0][0]:escapes[0][1]]
+			last = seq
+			if end < 0 {
+				b.WriteString(seq)
+			}
+			i = escapes[0][1]
+			escapes = escapes[1:]
+			continue
+		}
+		if pos == end {
+			b.WriteString(resetStyle + last)
+			end = -1
+		}
+		if end < 0 && next < len(cols) && pos == cols[next] {
+			b.WriteString(styles[next])
+			end = pos + length
+			next++
+		}
+		_, size := utf8.DecodeRuneInString(line[i:])
+		b.WriteString(line[i : i+size])
+		i += size
+		pos++
+	}
+	if end >= 0 {
+		b.WriteString(resetStyle + last)
+	}
+	return b.String()
+}
+
+//viewLine returns the row a rune position occupies once lines are wrapped to width,
+//following the wrapping rules of gocui
+func viewLine(lines []string, width int, m match) int {
+	row := 0
+	for _, line := range lines[:m.line] {
+		row += wrappedRows(utf8.RuneCountInString(line), width)
+	}
+	if width > 0 && utf8.RuneCountInString(lines[m.line]) >= width {
+		row += m.col / width
+	}
+	return row
+}
+
+func wrappedRows(length, width int) int {
+	if width <= 0 || length < width {
+		return 1
+	}
+	return length/width + 1
+}
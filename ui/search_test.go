@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindMatches(t *testing.T) {
+	lines := []string{`{`, ` "sku": "SKU_1",`, ` "title": "Sku één sku"`, `}`}
+	got := findMatches(lines, "sku")
+	want := []match{{1, 2}, {1, 9}, {2, 11}, {2, 19}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findMatches() = %v, want %v", got, want)
+	}
+	if got := findMatches(lines, ""); len(got) != 0 {
+		t.Errorf("findMatches() with empty query = %v, want none", got)
+	}
+}
+
+func TestHighlightLine(t *testing.T) {
+	line := "\x1b[34mab\x1b[0m cab"
+	got := highlightLine(line, []int{0, 4}, 2, []string{currentStyle, matchStyle})
+	want := "\x1b[34m" + currentStyle + "ab" + resetStyle + "\x1b[0m" + " c" + matchStyle + "ab" + resetStyle + "\x1b[0m"
+	if got != want {
+		t.Errorf("highlightLine() = %q, want %q", got, want)
+	}
+}
+
+func TestViewLine(t *testing.T) {
+	lines := []string{"short", "0123456789abcdefghij", "x"}
+	cases := []struct {
+		m    match
+		want int
+	}{
+		{match{0, 1}, 0},
+		{match{1, 3}, 1},
+		{match{1, 12}, 2},
+		{match{2, 0}, 4},
+	}
+	for _, c := range cases {
+		if got := viewLine(lines, 10, c.m); got != c.want {
+			t.Errorf("viewLine(%v) = %v, want %v", c.m, got, c.want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package ui
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/hassansin/gocui"
 	"github.com/logrusorgru/aurora"
@@ -14,6 +15,8 @@ type StatusLine struct {
 	height int
 	msg    string
 	name   string
+	right  string
+	last   string
 }
 
 func NewStatusLine(g *gocui.Gui) *StatusLine {
@@ -34,11 +37,12 @@ func (s *StatusLine) SetView(msg string) error {
 		v.Frame = false
 		//v.BgColor = gocui.ColorDefault | gocui.AttrReverse
 		//v.FgColor = gocui.ColorDefault | gocui.AttrReverse
-		fmt.Fprintln(v, msg)
+		s.v = v
+		s.last = msg
+		s.draw()
 		if _, err = s.g.SetViewOnTop(v.Name()); err != nil {
 			return errors.Wrap(err, "unable to set top view")
 		}
-		s.v = v
 		s.msg = msg
 		return nil
 	}
@@ -47,12 +51,38 @@ func (s *StatusLine) SetView(msg string) error {
 
 func (s *StatusLine) Update(msg string) {
 	s.g.Update(func(g *gocui.Gui) error {
-		s.v.Clear()
-		fmt.Fprint(s.v, msg)
+		s.last = msg
+		s.draw()
+		return nil
+	})
+}
+
+//SetRight sets a message that is shown at the right end of the status line next to the other messages
+func (s *StatusLine) SetRight(msg string) {
+	s.g.Update(func(g *gocui.Gui) error {
+		s.right = msg
+		s.draw()
 		return nil
 	})
 }
 
+func (s *StatusLine) draw() {
+	if s.v == nil {
+		return
+	}
+	s.v.Clear()
+	fmt.Fprint(s.v, s.last)
+	if s.right == "" {
+		return
+	}
+	width, _ := s.v.Size()
+	pad := width - len([]rune(strip(s.last))) - len([]rune(strip(s.right))) - 1
+	if pad < 1 {
+		pad = 1
+	}
+	fmt.Fprint(s.v, strings.Repeat(" ", pad), s.right)
+}
+
 func (s *StatusLine) UpdateSuccess(msg string) {
 	s.Update(fmt.Sprint(aurora.Green(msg)))
 }
@@ -0,0 +1,143 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hassansin/gocui"
+)
+
+const (
+	tabTitleWidth        = 20
+	currentTabTitleWidth = 50
+)
+
+//Tab is a response buffer in the main view
+type Tab struct {
+	title  string
+	body   interface{}
+	origin int
+}
+
+//Body returns the response loaded into the tab
+func (t *Tab) Body() interface{} {
+	return t.body
+}
+
+func (m *MainView) tab() *Tab {
+	return m.tabs[m.current]
+}
+
+//CurrentTab returns the tab that is currently displayed
+func (m *MainView) CurrentTab() *Tab {
+	return m.tab()
+}
+
+//ShowTab switches to t, it returns false if the tab has been closed
+func (m *MainView) ShowTab(t *Tab) bool {
+	for i, tab := range m.tabs {
+		if tab == t {
+			m.switchTab(i)
+			m.g.Update(func(g *gocui.Gui) error {
+				return m.scrollbarView.Redraw()
+			})
+			return true
+		}
+	}
+	return false
+}
+
+//NewTab adds an empty tab after the current one and switches to it
+func (m *MainView) NewTab() *Tab {
+	t := &Tab{}
+	m.tabs = append(m.tabs[:m.current+1], append([]*Tab{t}, m.tabs[m.current+1:]...)...)
+	m.switchTab(m.current + 1)
+	return t
+}
+
+//switchTab displays the idx-th tab, keeping the scroll position of the one we leave
+func (m *MainView) switchTab(idx int) {
+	if m.current < len(m.tabs) {
+		_, m.tabs[m.current].origin = m.View.Origin()
+	}
+	m.current = idx
+	m.refresh()
+	m.View.SetOrigin(0, m.tab().origin)
+	m.syncPinned()
+}
+
+func (m *MainView) nextTab(g *gocui.Gui, v *gocui.View) error {
+	m.switchTab((m.current + 1) % len(m.tabs))
+	return m.scrollbarView.Redraw()
+}
+
+func (m *MainView) prevTab(g *gocui.Gui, v *gocui.View) error {
+	m.switchTab((m.current - 1 + len(m.tabs)) % len(m.tabs))
+	return m.scrollbarView.Redraw()
+}
+
+func (m *MainView) newTab(g *gocui.Gui, v *gocui.View) error {
+	m.NewTab()
+	return m.scrollbarView.Redraw()
+}
+
+//closeTab closes the current tab, the last tab is only emptied
+func (m *MainView) closeTab(g *gocui.Gui, v *gocui.View) error {
+	if len(m.tabs) == 1 {
+		m.tabs[0] = &Tab{}
+		m.refresh()
+		return nil
+	}
+	m.tabs = append(m.tabs[:m.current], m.tabs[m.current+1:]...)
+	idx := m.current
+	if idx >= len(m.tabs) {
+		idx = len(m.tabs) - 1
+	}
+	m.current = len(m.tabs)
+	m.switchTab(idx)
+	return m.scrollbarView.Redraw()
+}
+
+func (m *MainView) moveTabLeft(g *gocui.Gui, v *gocui.View) error {
+	return m.moveTab(-1)
+}
+
+func (m *MainView) moveTabRight(g *gocui.Gui, v *gocui.View) error {
+	return m.moveTab(1)
+}
+
+func (m *MainView) moveTab(delta int) error {
+	idx := m.current + delta
+	if idx < 0 || idx >= len(m.tabs) {
+		return nil
+	}
+	m.tabs[m.current], m.tabs[idx] = m.tabs[idx], m.tabs[m.current]
+	m.current = idx
+	m.setTitle()
+	return nil
+}
+
+//tabBar lists the tabs, the current one in brackets
+func (m *MainView) tabBar() string {
+	labels := make([]string, len(m.tabs))
+	for i, t := range m.tabs {
+		title := t.title
+		if title == "" {
+			title = "empty"
+		}
+		if i == m.current {
+			labels[i] = fmt.Sprintf("[%v:%v]", i+1, truncate(title, currentTabTitleWidth))
+			continue
+		}
+		labels[i] = fmt.Sprintf("%v:%v", i+1, truncate(title, tabTitleWidth))
+	}
+	return strings.Join(labels, " ")
+}
+
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	return string(runes[:width-1]) + "…"
+}
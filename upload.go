@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"google.golang.org/api/googleapi"
+)
+
+//uploadMedia is the content type of uploaded files, the API works the actual type out itself
+var uploadMedia = googleapi.ContentType("application/octet-stream")
+
+//openUpload opens a file to upload and returns a progress updater that reports to the job running with ctx
+func openUpload(ctx context.Context, path string) (*os.File, googleapi.ProgressUpdater, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return f, func(current, total int64) {
+		reportProgress(ctx, Progress{Done: int(current), Total: int(info.Size())})
+	}, nil
+}
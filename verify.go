@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hassansin/androidpublisher/apierror"
+	"github.com/hassansin/androidpublisher/bulk"
+	"github.com/hassansin/androidpublisher/enums"
+	"github.com/hassansin/androidpublisher/orders"
+	"github.com/pkg/errors"
+	androidpublisher "google.golang.org/api/androidpublisher/v3"
+)
+
+//bulkTimeout is the default timeout of operations that work through a whole CSV file
+const bulkTimeout = 30 * time.Minute
+
+//BulkResult summarizes an operation run over the rows of a CSV file
+type BulkResult struct {
+	Input     string `json:"input"`
+	Output    string `json:"output"`
+	Rows      int    `json:"rows"`
+	Succeeded int    `json:"succeeded"`
+	Failed    int    `json:"failed"`
+	Skipped   int    `json:"skipped,omitempty"`
+}
+
+func bulkParams() []*Param {
+	return []*Param{{Name: "Input(csv)", Required: true}, {Name: "Output(csv)"}, {Name: "Concurrency"}}
+}
+
+//bulkRun calls fn for every row of the input CSV and writes the returned columns,
+//followed by an error column, to the output CSV
+func bulkRun(ctx context.Context, params []*Param, header []string, fn func(ctx context.Context, t *bulk.Table, i int) ([]string, error)) (interface{}, error) {
+	table, err := bulk.ReadCSV(params[0].Value)
+	if err != nil {
+		return nil, err
+	}
+	return bulkRunTable(ctx, params, table, header, fn)
+}
+
+//bulkRunTable is bulkRun for rows that were read already
+func bulkRunTable(ctx context.Context, params []*Param, table *bulk.Table, header []string, fn func(ctx context.Context, t *bulk.Table, i int) ([]string, error)) (interface{}, error) {
+	input := params[0].Value
+	output := params[1].Value
+	if output == "" {
+		output = strings.TrimSuffix(input, ".csv") + "-results.csv"
+	}
+	concurrency := 4
+	if params[2].Value != "" {
+		n, err := strconv.Atoi(params[2].Value)
+		if err != nil || n < 1 {
+			return nil, errors.Errorf("invalid concurrency %q", params[2].Value)
+		}
+		concurrency = n
+	}
+
+	rows := make([][]string, len(table.Rows))
+	errs := make([]error, len(table.Rows))
+	runErr := bulk.Run(ctx, len(table.Rows), concurrency, func(ctx context.Context, i int) error {
+		rows[i], errs[i] = fn(ctx, table, i)
+		return errs[i]
+	}, func(done, failed, total int) {
+		reportProgress(ctx, Progress{Done: done, Failed: failed, Total: total})
+	})
+
+	result := BulkResult{Input: input, Output: output, Rows: len(rows)}
+	for i := range rows {
+		msg := ""
+		switch {
+		case rows[i] == nil && errs[i] == nil:
+			result.Skipped++
+			msg = "not processed"
+		case errs[i] != nil:
+			result.Failed++
+			msg = apierror.Summary(errs[i])
+		default:
+			result.Succeeded++
+		}
+		if len(rows[i]) < len(header) {
+			row := make([]string, len(header))
+			copy(row, rows[i])
+			rows[i] = row
+		}
+		rows[i] = append(rows[i], msg)
+	}
+	if err := bulk.WriteCSV(output, append(header, "error"), rows); err != nil {
+		return nil, errors.Wrap(err, "unable to write results")
+	}
+	if runErr != nil {
+		return nil, runErr
+	}
+	return result, nil
+}
+
+//seenOrders collects the orders of the purchases found by a bulk operation for the order index
+type seenOrders struct {
+	mu        sync.Mutex
+	purchases []orders.Purchase
+}
+
+func (s *seenOrders) add(p orders.Purchase) {
+	s.mu.Lock()
+	s.purchases = append(s.purchases, p)
+	s.mu.Unlock()
+}
+
+//record adds the collected orders to the index, a failure doesn't fail the bulk operation
+func (s *seenOrders) record() {
+	if err := recordOrders(s.purchases...); err != nil {
+		status.UpdateError(err.Error())
+	}
+}
+
+func formatMillis(ms int64) string {
+	if ms == 0 {
+		return ""
+	}
+	return time.Unix(0, ms*int64(time.Millisecond)).UTC().Format(time.RFC3339)
+}
+
+func paymentStateName(v int64) string {
+	return enums.Name(v, "pending", "received", "free trial", "pending deferred upgrade/downgrade")
+}
+
+func acknowledgementStateName(v int64) string {
+	return enums.Name(v, "pending", "acknowledged")
+}
+
+//verifySubscriptionsOperation gets every subscription purchase listed in a CSV file with subscriptionId and token columns
+func verifySubscriptionsOperation(service *androidpublisher.Service, pkgName string) *Operation {
+	header := []string{"subscriptionId", "token", "orderId", "paymentState", "expiryTime", "autoRenewing", "cancelReason", "acknowledgementState"}
+	return &Operation{
+		Name:    "BulkGet",
+		Params:  bulkParams(),
+		Timeout: bulkTimeout,
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
+			s := androidpublisher.NewPurchasesSubscriptionsService(service)
+			seen := &seenOrders{}
+			defer seen.record()
+			return bulkRun(ctx, params, header, func(ctx context.Context, t *bulk.Table, i int) ([]string, error) {
+				id, token := t.Value(i, t.Column("subscriptionId", "sku", "productId")), t.Value(i, t.Column("token", "purchaseToken"))
+				row := []string{id, token}
+				if id == "" || token == "" {
+					return row, errors.New("missing subscriptionId or token")
+				}
+				sub, err := s.Get(pkgName, id, token).Context(ctx).Do()
+				if err != nil {
+					return row, err
+				}
+				seen.add(orders.Purchase{OrderID: sub.OrderId, Kind: orders.Subscription, ProductID: id, Token: token, Source: "Purchases.subscriptions.BulkGet"})
+				cancelReason := ""
+				if !sub.AutoRenewing {
+					cancelReason = enums.Name(sub.CancelReason, "user", "system", "replaced", "developer")
+				}
+				return append(row,
+					sub.OrderId,
+					paymentStateName(sub.PaymentState),
+					formatMillis(sub.ExpiryTimeMillis),
+					strconv.FormatBool(sub.AutoRenewing),
+					cancelReason,
+					acknowledgementStateName(sub.AcknowledgementState),
+				), nil
+			})
+		},
+	}
+}
+
+//verifyProductsOperation gets every product purchase listed in a CSV file with productId and token columns
+func verifyProductsOperation(service *androidpublisher.Service, pkgName string) *Operation {
+	header := []string{"productId", "token", "orderId", "purchaseState", "consumptionState", "acknowledgementState", "purchaseTime"}
+	return &Operation{
+		Name:    "BulkGet",
+		Params:  bulkParams(),
+		Timeout: bulkTimeout,
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
+			s := androidpublisher.NewPurchasesProductsService(service)
+			seen := &seenOrders{}
+			defer seen.record()
+			return bulkRun(ctx, params, header, func(ctx context.Context, t *bulk.Table, i int) ([]string, error) {
+				id, token := t.Value(i, t.Column("productId", "sku")), t.Value(i, t.Column("token", "purchaseToken"))
+				row := []string{id, token}
+				if id == "" || token == "" {
+					return row, errors.New("missing productId or token")
+				}
+				p, err := s.Get(pkgName, id, token).Context(ctx).Do()
+				if err != nil {
+					return row, err
+				}
+				seen.add(orders.Purchase{OrderID: p.OrderId, Kind: orders.Product, ProductID: id, Token: token, Source: "Purchases.products.BulkGet"})
+				return append(row,
+					p.OrderId,
+					enums.PurchaseState(p.PurchaseState),
+					enums.Name(p.ConsumptionState, "not consumed", "consumed"),
+					acknowledgementStateName(p.AcknowledgementState),
+					formatMillis(p.PurchaseTimeMillis),
+				), nil
+			})
+		},
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/hassansin/androidpublisher/bulk"
+	"github.com/hassansin/androidpublisher/orders"
+	"github.com/hassansin/androidpublisher/ui"
+	"github.com/hassansin/androidpublisher/voided"
+	"github.com/pkg/errors"
+	androidpublisher "google.golang.org/api/androidpublisher/v3"
+)
+
+//voidedWindow is how far back the API returns voided purchases
+const voidedWindow = 30 * 24 * time.Hour
+
+//voidedMu serializes syncs and reports of the voided purchases store
+var voidedMu sync.Mutex
+
+func voidedPath(pkgName string) string {
+	return filepath.Join(dataDir(), "voided-"+pkgName+".json")
+}
+
+//VoidedSyncResult summarizes a sync of the voided purchases
+type VoidedSyncResult struct {
+	Since   string `json:"since"`
+	Fetched int    `json:"fetched"`
+	Added   int    `json:"added"`
+	Stored  int    `json:"stored"`
+	Last    string `json:"lastVoidedTime,omitempty"`
+	File    string `json:"file"`
+}
+
+//syncVoidedOperation fetches, across all pages, the purchases voided after the last one seen and stores them
+func syncVoidedOperation(service *androidpublisher.Service, pkgName string) *Operation {
+	return &Operation{
+		Name:    "Sync",
+		Timeout: bulkTimeout,
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
+			voidedMu.Lock()
+			defer voidedMu.Unlock()
+			store, err := voided.Open(voidedPath(pkgName))
+			if err != nil {
+				return nil, err
+			}
+			//the API rejects start times older than its window
+			start := store.Last + 1
+			if oldest := time.Now().Add(-voidedWindow+time.Minute).UnixNano() / int64(time.Millisecond); start < oldest {
+				start = oldest
+			}
+
+			s := androidpublisher.NewPurchasesVoidedpurchasesService(service)
+			var fetched []*androidpublisher.VoidedPurchase
+			token := ""
+			for {
+				//type 1 includes voided subscription purchases
+				call := s.List(pkgName).StartTime(start).Type(1).MaxResults(1000)
+				if token != "" {
+					call.Token(token)
+				}
+				res, err := call.Context(ctx).Do()
+				if err != nil {
+					return nil, err
+				}
+				fetched = append(fetched, res.VoidedPurchases...)
+				if res.TokenPagination == nil || res.TokenPagination.NextPageToken == "" {
+					break
+				}
+				token = res.TokenPagination.NextPageToken
+			}
+
+			added := store.Add(fetched)
+			var purchases []orders.Purchase
+			for _, p := range fetched {
+				purchases = append(purchases, orders.Purchase{OrderID: p.OrderId, Token: p.PurchaseToken, Source: "voided purchases"})
+			}
+			if err := recordOrders(purchases...); err != nil {
+				return nil, err
+			}
+			if err := store.Save(); err != nil {
+				return nil, errors.Wrap(err, "unable to save voided purchases")
+			}
+			return VoidedSyncResult{
+				Since:   formatMillis(start),
+				Fetched: len(fetched),
+				Added:   added,
+				Stored:  len(store.Purchases),
+				Last:    formatMillis(store.Last),
+				File:    voidedPath(pkgName),
+			}, nil
+		},
+	}
+}
+
+//voidedReportOperation groups the stored voided purchases by day, source and reason, optionally writing a CSV
+func voidedReportOperation(pkgName string) *Operation {
+	return &Operation{
+		Name:   "Report",
+		Params: []*Param{{Name: "From(YYYY-MM-DD)"}, {Name: "To(YYYY-MM-DD, exclusive)"}, {Name: "Output(csv)"}},
+		Do: func(ctx context.Context, params []*Param) (interface{}, error) {
+			var from, to time.Time
+			for i, t := range []*time.Time{&from, &to} {
+				if params[i].Value == "" {
+					continue
+				}
+				var err error
+				if *t, err = time.Parse("2006-01-02", params[i].Value); err != nil {
+					return nil, errors.Errorf("invalid date %q, expected YYYY-MM-DD", params[i].Value)
+				}
+			}
+			voidedMu.Lock()
+			store, err := voided.Open(voidedPath(pkgName))
+			voidedMu.Unlock()
+			if err != nil {
+				return nil, err
+			}
+			groups := voided.Report(store.Purchases, from, to)
+			out := fmt.Sprintf("%v voided purchases stored, last synced up to %v (days are UTC)\n\n%v", len(store.Purchases), formatMillis(store.Last), voided.Table(groups))
+			if params[2].Value != "" {
+				header, rows := voided.CSV(groups)
+				if err := bulk.WriteCSV(params[2].Value, header, rows); err != nil {
+					return nil, err
+				}
+				out += "\nWritten to " + params[2].Value
+			}
+			return ui.Text(out), nil
+		},
+	}
+}
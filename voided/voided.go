@@ -0,0 +1,165 @@
+//Package voided keeps a local copy of the voided purchases and reports on them
+package voided
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	androidpublisher "google.golang.org/api/androidpublisher/v3"
+)
+
+//Sources and reasons of voided purchases as named by the API documentation
+var (
+	Sources = []string{"user", "developer", "google"}
+	Reasons = []string{"other", "remorse", "not_received", "defective", "accidental_purchase", "fraud", "friendly_fraud", "chargeback"}
+)
+
+//Store is the JSON file with the voided purchases synced so far
+type Store struct {
+	path string
+	//Last is the latest voidedTimeMillis seen, the next sync starts after it
+	Last      int64                              `json:"lastVoidedTimeMillis,string"`
+	Purchases []*androidpublisher.VoidedPurchase `json:"voidedPurchases"`
+}
+
+//Open loads the store at path, a missing file is an empty store
+func Open(path string) (*Store, error) {
+	s := &Store{path: path}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, errors.Wrapf(err, "unable to read voided purchases (%v)", path)
+	}
+	return s, nil
+}
+
+//Add stores the purchases that aren't known yet, by purchase token and voided time, and returns how many were new
+func (s *Store) Add(purchases []*androidpublisher.VoidedPurchase) int {
+	known := map[string]bool{}
+	for _, p := range s.Purchases {
+		known[key(p)] = true
+	}
+	added := 0
+	for _, p := range purchases {
+		if known[key(p)] {
+			continue
+		}
+		known[key(p)] = true
+		s.Purchases = append(s.Purchases, p)
+		added++
+		if p.VoidedTimeMillis > s.Last {
+			s.Last = p.VoidedTimeMillis
+		}
+	}
+	sort.SliceStable(s.Purchases, func(i, j int) bool { return s.Purchases[i].VoidedTimeMillis < s.Purchases[j].VoidedTimeMillis })
+	return added
+}
+
+func key(p *androidpublisher.VoidedPurchase) string {
+	return fmt.Sprintf("%v/%v", p.PurchaseToken, p.VoidedTimeMillis)
+}
+
+//Save writes the store, replacing the file only once it's completely written
+func (s *Store) Save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	//every save writes a file of its own, concurrent saves can't rename each other's partly written files
+	f, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), s.path)
+}
+
+//Group is the number of purchases voided on a day, UTC, by a source for a reason
+type Group struct {
+	Day    string
+	Source string
+	Reason string
+	Count  int
+}
+
+//Report groups the purchases voided in [from, to) by day, source and reason. Zero times aren't bounds.
+func Report(purchases []*androidpublisher.VoidedPurchase, from, to time.Time) []Group {
+	counts := map[Group]int{}
+	for _, p := range purchases {
+		voided := time.Unix(0, p.VoidedTimeMillis*int64(time.Millisecond)).UTC()
+		if (!from.IsZero() && voided.Before(from)) || (!to.IsZero() && !voided.Before(to)) {
+			continue
+		}
+		counts[Group{Day: voided.Format("2006-01-02"), Source: name(Sources, p.VoidedSource), Reason: name(Reasons, p.VoidedReason)}]++
+	}
+	groups := make([]Group, 0, len(counts))
+	for g, count := range counts {
+		g.Count = count
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		a, b := groups[i], groups[j]
+		if a.Day != b.Day {
+			return a.Day < b.Day
+		}
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		return a.Reason < b.Reason
+	})
+	return groups
+}
+
+func name(names []string, v int64) string {
+	if v >= 0 && int(v) < len(names) {
+		return names[v]
+	}
+	return fmt.Sprint(v)
+}
+
+//CSV returns the header and rows of the report
+func CSV(groups []Group) ([]string, [][]string) {
+	rows := make([][]string, len(groups))
+	for i, g := range groups {
+		rows[i] = []string{g.Day, g.Source, g.Reason, fmt.Sprint(g.Count)}
+	}
+	return []string{"day", "source", "reason", "count"}, rows
+}
+
+//Table renders the report as an aligned text table with a total
+func Table(groups []Group) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DAY\tSOURCE\tREASON\tCOUNT")
+	total := 0
+	for _, g := range groups {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", g.Day, g.Source, g.Reason, g.Count)
+		total += g.Count
+	}
+	fmt.Fprintf(w, "\t\tTOTAL\t%v\n", total)
+	w.Flush()
+	return b.String()
+}
@@ -0,0 +1,102 @@
+package voided
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	androidpublisher "google.golang.org/api/androidpublisher/v3"
+)
+
+func purchase(token, voided string, source, reason int64) *androidpublisher.VoidedPurchase {
+	t, _ := time.Parse(time.RFC3339, voided)
+	return &androidpublisher.VoidedPurchase{
+		PurchaseToken:    token,
+		VoidedTimeMillis: t.UnixNano() / int64(time.Millisecond),
+		VoidedSource:     source,
+		VoidedReason:     reason,
+	}
+}
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "voided.json")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := purchase("a", "2019-05-01T10:00:00Z", 0, 1)
+	b := purchase("b", "2019-05-02T10:00:00Z", 2, 7)
+	if added := s.Add([]*androidpublisher.VoidedPurchase{b, a}); added != 2 || s.Last != b.VoidedTimeMillis {
+		t.Errorf("Add() = %v, last %v", added, s.Last)
+	}
+	if added := s.Add([]*androidpublisher.VoidedPurchase{a}); added != 0 {
+		t.Errorf("adding a known purchase = %v", added)
+	}
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+	s, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Purchases) != 2 || s.Purchases[0].PurchaseToken != "a" || s.Last != b.VoidedTimeMillis {
+		t.Errorf("unexpected store %+v", s)
+	}
+}
+
+func TestReport(t *testing.T) {
+	purchases := []*androidpublisher.VoidedPurchase{
+		purchase("a", "2019-05-01T10:00:00Z", 0, 1),
+		purchase("b", "2019-05-01T23:00:00Z", 0, 1),
+		purchase("c", "2019-05-02T10:00:00Z", 2, 7),
+		purchase("d", "2019-05-03T10:00:00Z", 1, 9),
+	}
+	to, _ := time.Parse("2006-01-02", "2019-05-03")
+	groups := Report(purchases, time.Time{}, to)
+	want := []Group{{"2019-05-01", "user", "remorse", 2}, {"2019-05-02", "google", "chargeback", 1}}
+	if !reflect.DeepEqual(groups, want) {
+		t.Errorf("Report() = %v", groups)
+	}
+	if groups := Report(purchases, to, time.Time{}); len(groups) != 1 || groups[0].Reason != "9" {
+		t.Errorf("Report(from) = %v", groups)
+	}
+	if table := Table(want); !strings.Contains(strings.Join(strings.Fields(table), " "), "TOTAL 3") {
+		t.Errorf("unexpected table\n%v", table)
+	}
+}
+
+func TestConcurrentSaves(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "voided.json")
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		s, err := Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Add([]*androidpublisher.VoidedPurchase{{PurchaseToken: strings.Repeat(fmt.Sprint(i), 10000), VoidedTimeMillis: int64(i)}})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.Save(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if _, err := Open(path); err != nil {
+		t.Errorf("torn file after concurrent saves: %v", err)
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("temporary files left behind: %v", len(files))
+	}
+}